@@ -0,0 +1,69 @@
+package doc
+
+import (
+	"encoding/binary"
+	"io"
+)
+
+// ParseASCII extracts the text of the .doc file in r normalized for
+// indexing: ASCII letters lowercased, digits kept and every other
+// character, including spaces and paragraph marks, written as a space.
+// Each character outside field instructions gives exactly one byte, and
+// no character is decoded, which makes this much faster than ParseDoc.
+func ParseASCII(r io.Reader) (string, error) {
+	ra, release, err := readerAt(r, Options{})
+	if err != nil {
+		return "", wrapError(err)
+	}
+	defer release()
+
+	pd, err := openDoc(ra, Options{})
+	if err != nil {
+		return "", wrapError(err)
+	}
+
+	w := newTextWriter(Options{})
+	w.asciiOnly = true
+	if err := writeText(pd, w); err != nil {
+		return "", wrapError(err)
+	}
+	return w.buf.String(), nil
+}
+
+// asciiFold maps each ASCII character to the byte ParseASCII writes for it
+var asciiFold = func() (fold [128]byte) {
+	for c := range fold {
+		switch {
+		case c >= 'a' && c <= 'z', c >= '0' && c <= '9':
+			fold[c] = byte(c)
+		case c >= 'A' && c <= 'Z':
+			fold[c] = byte(c) + 'a' - 'A'
+		default:
+			fold[c] = ' '
+		}
+	}
+	return fold
+}()
+
+// translateASCII writes b as ParseASCII normalizes it
+func translateASCII(b []byte, w *textWriter, compressed bool) {
+	width := 2
+	if compressed {
+		width = 1
+	}
+	for i := 0; i+width <= len(b) && !w.full(); i += width {
+		char := uint16(b[i])
+		if !compressed {
+			char = binary.LittleEndian.Uint16(b[i:])
+		}
+		if w.field(char) || w.inFieldCode() {
+			continue
+		}
+		c := byte(' ')
+		if char < 0x80 {
+			c = asciiFold[char]
+		}
+		w.buf.WriteByte(c)
+		w.chars++
+	}
+}
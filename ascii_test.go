@@ -0,0 +1,34 @@
+package doc
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestParseASCII(t *testing.T) {
+	b := testDoc{pieces: []testPiece{
+		{text: "Hello, World! Page \x13 PAGE \x142\x15.\r", compressed: true},
+		{text: "Café №42 — ÜBER-test\r"},
+	}}.build()
+
+	s, err := ParseASCII(bytes.NewReader(b))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "hello  world  page 2  caf   42    ber test "; s != want {
+		t.Errorf("expected %q, got %q", want, s)
+	}
+}
+
+func BenchmarkParseASCII(b *testing.B) {
+	doc := testDoc{pieces: []testPiece{
+		{text: string(bytes.Repeat([]byte("The quick brown fox, 42 times.\r"), 2000)), compressed: true},
+	}}.build()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := ParseASCII(bytes.NewReader(doc)); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
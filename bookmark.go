@@ -0,0 +1,134 @@
+package doc
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf16"
+
+	"github.com/richardlehane/mscfb"
+)
+
+var (
+	// ErrBookmarkNotFound is returned by ExtractBookmarkText when the
+	// document has no bookmark of the given name
+	ErrBookmarkNotFound = errors.New("bookmark not found")
+
+	errInvalidSttb = errors.New("invalid STTB structure")
+)
+
+// bookmark is a named CP range of the main document
+type bookmark struct {
+	name       string
+	start, end int
+}
+
+// ExtractBookmarkText returns the text of the bookmark called name in the
+// .doc file in r. Names are matched ignoring case, as Word does.
+func ExtractBookmarkText(r io.Reader, name string) (string, error) {
+	ra, release, err := readerAt(r, Options{})
+	if err != nil {
+		return "", wrapError(err)
+	}
+	defer release()
+
+	pd, err := openDoc(ra, Options{})
+	if err != nil {
+		return "", wrapError(err)
+	}
+	bookmarks, err := getBookmarks(pd.table, pd.fib)
+	if err != nil {
+		return "", wrapError(err)
+	}
+	for _, bk := range bookmarks {
+		if !strings.EqualFold(bk.name, name) {
+			continue
+		}
+		if bk.end <= bk.start { // a zero cpTo would read the whole document
+			return "", nil
+		}
+		w := newTextWriter(Options{})
+		w.cpFrom, w.cpTo = bk.start, bk.end
+		if err := writeText(pd, w); err != nil {
+			return "", wrapError(err)
+		}
+		return w.buf.String(), nil
+	}
+	return "", fmt.Errorf("%w: %q", ErrBookmarkNotFound, name)
+}
+
+// getBookmarks reads the names of the document's bookmarks from SttbfBkmk,
+// their starts from PlcfBkf and their ends from PlcfBkl, which each FBKF
+// indexes (sections 2.9.282, 2.9.184 and 2.9.186)
+func getBookmarks(table *mscfb.File, fib *fib) ([]bookmark, error) {
+	rg := fib.fibRgFcLcb
+	b, err := readTableBytes(table, rg.fcSttbfBkmk, rg.lcbSttbfBkmk)
+	if err != nil || b == nil {
+		return nil, err
+	}
+	names, _, err := parseSttb(b)
+	if err != nil {
+		return nil, err
+	}
+
+	b, err = readTableBytes(table, rg.fcPlcfBkf, rg.lcbPlcfBkf)
+	if err != nil {
+		return nil, err
+	}
+	bkf, err := parsePlc(b, 4) // FBKF is 4 bytes
+	if err != nil {
+		return nil, err
+	}
+	b, err = readTableBytes(table, rg.fcPlcfBkl, rg.lcbPlcfBkl)
+	if err != nil {
+		return nil, err
+	}
+	bkl, err := parsePlc(b, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	bookmarks := make([]bookmark, 0, len(names))
+	for i, name := range names {
+		if i >= len(bkf.aData) {
+			break
+		}
+		ibkl := int(binary.LittleEndian.Uint16(bkf.aData[i]))
+		if ibkl >= len(bkl.aCP) {
+			continue
+		}
+		bookmarks = append(bookmarks, bookmark{name: name, start: bkf.aCP[i], end: bkl.aCP[ibkl]})
+	}
+	return bookmarks, nil
+}
+
+// parseSttb returns the strings of an extended STTB without extra data
+// (section 2.2.4), and the bytes following it
+func parseSttb(b []byte) ([]string, []byte, error) {
+	if len(b) < 6 || binary.LittleEndian.Uint16(b) != 0xFFFF {
+		return nil, nil, errInvalidSttb
+	}
+	n := int(binary.LittleEndian.Uint16(b[2:]))
+	cbExtra := int(binary.LittleEndian.Uint16(b[4:]))
+	b = b[6:]
+
+	strs := make([]string, 0, n)
+	for i := 0; i < n; i++ {
+		if len(b) < 2 {
+			return nil, nil, errInvalidSttb
+		}
+		cch := int(binary.LittleEndian.Uint16(b))
+		if len(b) < 2+cch*2+cbExtra {
+			return nil, nil, errInvalidSttb
+		}
+		units := make([]uint16, cch)
+		for i := range units {
+			units[i] = binary.LittleEndian.Uint16(b[2+i*2:])
+		}
+		strs = append(strs, string(utf16.Decode(units)))
+		b = b[2+cch*2+cbExtra:]
+	}
+	return strs, b, nil
+}
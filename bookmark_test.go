@@ -0,0 +1,84 @@
+package doc
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func TestExtractBookmarkText(t *testing.T) {
+	b := testDoc{
+		pieces: []testPiece{
+			{text: "Dear Alice Smith,\r", compressed: true},
+			{text: "Your order ships today.\r"},
+		},
+		bookmarks: []bookmark{
+			{name: "Name", start: 5, end: 16},
+			{name: "Insert", start: 17, end: 17},
+			{name: "Status", start: 29, end: 41},
+		},
+	}.build()
+
+	for name, want := range map[string]string{
+		"Name":   "Alice Smith",
+		"status": "ships today.",
+		"Insert": "",
+	} {
+		text, err := ExtractBookmarkText(bytes.NewReader(b), name)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if text != want {
+			t.Errorf("%s: expected %q, got %q", name, want, text)
+		}
+	}
+
+	if _, err := ExtractBookmarkText(bytes.NewReader(b), "Missing"); !errors.Is(err, ErrBookmarkNotFound) {
+		t.Errorf("expected ErrBookmarkNotFound, got %v", err)
+	}
+}
+
+func TestParseSmartTag(t *testing.T) {
+	// Word stores a smart tag as a factoid bookmark over the tagged text,
+	// kept apart from ordinary bookmarks, and its properties in the table
+	// stream; nothing marks it in the text itself. Ordinary bookmarks stand
+	// in for the factoids here, and the tagged text gets runs of its own,
+	// in pieces of both widths.
+	b := testDoc{
+		pieces: []testPiece{
+			{text: "Call ", compressed: true},
+			{text: "Contoso Ltd.", grpprl: []byte{0x6D, 0x48, 0x09, 0x04}},
+			{text: " at ", compressed: true},
+			{text: "555-0100", compressed: true, grpprl: []byte{0x6D, 0x48, 0x09, 0x04}},
+			{text: " today.\r", compressed: true},
+		},
+		bookmarks: []bookmark{
+			{name: "_FactoidCompany", start: 5, end: 17},
+			{name: "_FactoidPhone", start: 21, end: 29},
+		},
+	}.build()
+
+	buf, err := ParseDoc(bytes.NewReader(b))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := buf.(*bytes.Buffer).String(); s != "Call Contoso Ltd. at 555-0100 today.\r" {
+		t.Errorf("expected the tagged text in the body, got %q", s)
+	}
+
+	var text bytes.Buffer
+	err = WalkRuns(bytes.NewReader(b), func(e RunEvent) error {
+		text.WriteString(e.Text)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := text.String(); s != "Call Contoso Ltd. at 555-0100 today." {
+		t.Errorf("expected the tagged text in the runs, got %q", s)
+	}
+
+	if s, err := ExtractBookmarkText(bytes.NewReader(b), "_FactoidCompany"); err != nil || s != "Contoso Ltd." {
+		t.Errorf("expected the tagged range, got %q, %v", s, err)
+	}
+}
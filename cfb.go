@@ -0,0 +1,62 @@
+package doc
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+	"os"
+
+	"github.com/mattetti/filebuffer"
+	"github.com/richardlehane/mscfb"
+)
+
+var (
+	errCFBHeader = errors.New("compound file header sector counts exceed the file size")
+)
+
+// maxCFBSize bounds the size a compound file header may claim when the
+// size of the input isn't known
+const maxCFBSize = 1 << 32
+
+// newCFB is mscfb.New, after checking the sector counts in the header
+// against the size of ra. mscfb preallocates from these counts, so a corrupt
+// header could otherwise exhaust memory.
+func newCFB(ra io.ReaderAt) (*mscfb.Reader, error) {
+	header := make([]byte, 76)
+	if _, err := ra.ReadAt(header, 0); err == nil {
+		size, ok := sizeOf(ra)
+		if !ok {
+			size = maxCFBSize
+		}
+		sectorSize := int64(1) << (binary.LittleEndian.Uint16(header[30:]) & 0x1F)
+		for _, off := range []int{40, 44, 64, 72} { // directory, FAT, mini FAT and DIFAT sector counts
+			if int64(binary.LittleEndian.Uint32(header[off:]))*sectorSize > size {
+				return nil, errCFBHeader
+			}
+		}
+	}
+	return mscfb.New(ra)
+}
+
+// inputSize returns the size of the data in ra, or -1 if it isn't known
+func inputSize(ra io.ReaderAt) int64 {
+	if size, ok := sizeOf(ra); ok {
+		return size
+	}
+	return -1
+}
+
+// sizeOf returns the size of the data in ra, if it can be found cheaply
+func sizeOf(ra io.ReaderAt) (int64, bool) {
+	switch v := ra.(type) {
+	case interface{ Size() int64 }: // bytes.Reader, strings.Reader, io.SectionReader
+		return v.Size(), true
+	case *filebuffer.Buffer:
+		return int64(v.Buff.Len()), true
+	case *os.File:
+		if fi, err := v.Stat(); err == nil {
+			return fi.Size(), true
+		}
+	}
+	return 0, false
+}
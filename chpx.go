@@ -0,0 +1,169 @@
+package doc
+
+import (
+	"encoding/binary"
+	"sort"
+	"unicode"
+
+	"github.com/richardlehane/mscfb"
+)
+
+// charProps holds the character properties this package reads from a Chpx
+type charProps struct {
+	lid    uint16 // language of Latin text, 0 if not set
+	lidFE  uint16 // language of East Asian text
+	lidBi  uint16 // language of complex script (right-to-left) text
+	hidden bool
+	caps   bool // all caps or small caps
+	rtl    bool
+	spec   bool // the character is special, such as a picture
+	hasPic bool // picLocation is set
+	ole    bool // the 0x01 character is an OLE object
+	// offset in the Data stream of the picture at a 0x01 character, which
+	// also names the ObjectPool storage of an OLE object
+	picLocation int
+}
+
+// lang returns the language of text formatted with p, defaulting to lid
+func (p charProps) lang(text string, lid uint16) uint16 {
+	l := p.lid
+	for _, r := range text {
+		if p.lidFE != 0 && unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul) {
+			l = p.lidFE
+			break
+		}
+		if p.lidBi != 0 && unicode.In(r, unicode.Arabic, unicode.Hebrew) {
+			l = p.lidBi
+			break
+		}
+	}
+	if l == 0 {
+		return lid
+	}
+	return l
+}
+
+// chpxRun is a range [fc, fcEnd) of bytes in the WordDocument stream that
+// share the same character properties
+type chpxRun struct {
+	fc, fcEnd int
+	props     charProps
+}
+
+// getChpxRuns reads the character properties of the document from the
+// ChpxFkp pages listed in PlcBteChpx (section 2.8.6), in FC order
+func getChpxRuns(wordDoc, table *mscfb.File, fib *fib) ([]chpxRun, error) {
+	var runs []chpxRun
+	err := readFkps(wordDoc, table, fib.fibRgFcLcb.fcPlcfBteChpx, fib.fibRgFcLcb.lcbPlcfBteChpx, func(page []byte) error {
+		fkp, err := parseChpxFkp(page)
+		runs = append(runs, fkp...)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(runs, func(i, j int) bool { return runs[i].fc < runs[j].fc })
+	return runs, nil
+}
+
+// parse a ChpxFkp (section 2.9.33)
+func parseChpxFkp(page []byte) ([]chpxRun, error) {
+	crun := int(page[511])
+	rgbStart := (crun + 1) * 4
+	if crun == 0 || rgbStart+crun > 511 {
+		return nil, errInvalidFkp
+	}
+
+	runs := make([]chpxRun, crun)
+	for i := range runs {
+		runs[i].fc = getInt(page, i*4)
+		runs[i].fcEnd = getInt(page, (i+1)*4)
+		offset := int(page[rgbStart+i]) * 2 // 0 means default properties
+		if offset == 0 {
+			continue
+		}
+		cb := int(page[offset])
+		if offset+1+cb > 511 {
+			return nil, errInvalidFkp
+		}
+		runs[i].props = parseCharProps(page[offset+1 : offset+1+cb])
+	}
+	return runs, nil
+}
+
+func parseCharProps(grpprl []byte) charProps {
+	var p charProps
+	forEachSprm(grpprl, func(sprm uint16, operand []byte) {
+		switch sprm {
+		case sprmCRgLid0_80, sprmCRgLid0:
+			p.lid = binary.LittleEndian.Uint16(operand)
+		case sprmCRgLid1_80, sprmCRgLid1:
+			p.lidFE = binary.LittleEndian.Uint16(operand)
+		case sprmCLidBi:
+			p.lidBi = binary.LittleEndian.Uint16(operand)
+		case sprmCFVanish:
+			p.hidden = toggle(operand[0])
+		case sprmCFBiDi:
+			p.rtl = toggle(operand[0])
+		case sprmCFSpec:
+			p.spec = toggle(operand[0])
+		case sprmCFOle2, sprmCFObj:
+			p.ole = p.ole || toggle(operand[0])
+		case sprmCPicLocation:
+			p.hasPic = true
+			p.picLocation = int(binary.LittleEndian.Uint32(operand))
+		case sprmCFCaps, sprmCFSmallCaps:
+			p.caps = p.caps || toggle(operand[0])
+		}
+	})
+	return p
+}
+
+// toggle reads a ToggleOperand (section 2.9.321). Values relative to the
+// style are taken relative to off, as styles aren't read.
+func toggle(operand byte) bool {
+	return operand == 0x01 || operand == 0x81
+}
+
+// pieceRun is the part b[from:to] of a piece's bytes with the same
+// character properties
+type pieceRun struct {
+	from, to int
+	props    charProps
+}
+
+// splitPiece splits the piece stored at [start, end) in the WordDocument
+// stream at the boundaries of runs. Bytes no run covers get default
+// properties.
+func splitPiece(runs []chpxRun, start, end int, compressed bool) []pieceRun {
+	offset := func(fc int) int {
+		if compressed {
+			return fc - start
+		}
+		return (fc - start) &^ 1 // keep to character boundaries
+	}
+
+	var split []pieceRun
+	pos := start
+	i := sort.Search(len(runs), func(i int) bool { return runs[i].fcEnd > start })
+	for ; i < len(runs) && runs[i].fc < end; i++ {
+		from, to := runs[i].fc, runs[i].fcEnd
+		if from < pos {
+			from = pos
+		}
+		if to > end {
+			to = end
+		}
+		if from > pos {
+			split = append(split, pieceRun{from: offset(pos), to: offset(from)})
+		}
+		if to > from {
+			split = append(split, pieceRun{from: offset(from), to: offset(to), props: runs[i].props})
+			pos = to
+		}
+	}
+	if pos < end || len(split) == 0 {
+		split = append(split, pieceRun{from: offset(pos), to: offset(end)})
+	}
+	return split
+}
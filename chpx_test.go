@@ -0,0 +1,90 @@
+package doc
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+)
+
+func TestParseHiddenText(t *testing.T) {
+	vanish := []byte{0x3C, 0x08, 0x01}  // sprmCFVanish on
+	outline := []byte{0x38, 0x08, 0x01} // sprmCFOutline on, which isn't hidden
+	b := testDoc{pieces: []testPiece{
+		{text: "Visible ", compressed: true},
+		{text: "outlined ", compressed: true, grpprl: outline},
+		{text: "hidden note ", compressed: true, grpprl: vanish},
+		{text: "text\r", compressed: true},
+		{text: "索引", grpprl: vanish},
+	}}.build()
+
+	buf, err := ParseDoc(bytes.NewReader(b))
+	if err != nil {
+		t.Fatal("expected successful parse", err)
+	}
+	if s := buf.(*bytes.Buffer).String(); s != "Visible outlined text\r" {
+		t.Errorf("expected hidden runs to be excluded |%s|", s)
+	}
+
+	buf, err = ParseDocWithOptions(bytes.NewReader(b), Options{IncludeHiddenText: true})
+	if err != nil {
+		t.Fatal("expected successful parse", err)
+	}
+	if s := buf.(*bytes.Buffer).String(); s != "Visible outlined hidden note text\r索引" {
+		t.Errorf("expected hidden runs to be included |%s|", s)
+	}
+}
+
+func TestParseCaseFormatting(t *testing.T) {
+	b := testDoc{pieces: []testPiece{
+		{text: "Chapter ", compressed: true},
+		{text: "Introduction", compressed: true, grpprl: []byte{0x3B, 0x08, 0x01}}, // sprmCFCaps on
+		{text: "\rÉtude", grpprl: []byte{0x3A, 0x08, 0x01}},                        // sprmCFSmallCaps on
+		{text: " finale\r", compressed: true},
+	}}.build()
+
+	buf, err := ParseDoc(bytes.NewReader(b))
+	if err != nil {
+		t.Fatal("expected successful parse", err)
+	}
+	if s := buf.(*bytes.Buffer).String(); s != "Chapter Introduction\rÉtude finale\r" {
+		t.Errorf("expected the stored case by default |%s|", s)
+	}
+
+	buf, err = ParseDocWithOptions(bytes.NewReader(b), Options{ApplyCaseFormatting: true})
+	if err != nil {
+		t.Fatal("expected successful parse", err)
+	}
+	if s := buf.(*bytes.Buffer).String(); s != "Chapter INTRODUCTION\rÉTUDE finale\r" {
+		t.Errorf("expected caps runs to be uppercased |%s|", s)
+	}
+}
+
+func TestParseInvalidFkps(t *testing.T) {
+	entries := testDoc{pieces: []testPiece{
+		{text: "Bold ", compressed: true, grpprl: []byte{0x3B, 0x08, 0x01}, papx: []byte{0x41, 0x24, 0x01}},
+		{text: "text\r", compressed: true},
+	}}.entries()
+	wordDoc, table := entries[0].data, entries[1].data
+	// point the crun and cpara of both FKP pages past the page
+	for _, slot := range []int{24, 26} { // PlcBteChpx, PlcBtePapx
+		fc := binary.LittleEndian.Uint32(wordDoc[154+slot*4:])
+		pn := binary.LittleEndian.Uint32(table[fc+8:])
+		wordDoc[pn*512+511] = 0xFF
+	}
+	b := buildCFB(entries)
+
+	buf, err := ParseDoc(bytes.NewReader(b))
+	if err != nil {
+		t.Fatal("expected the text despite the formatting", err)
+	}
+	if s := buf.(*bytes.Buffer).String(); s != "Bold text\r" {
+		t.Errorf("expected %q, got %q", "Bold text\r", s)
+	}
+	d, err := ParseDocument(bytes.NewReader(b))
+	if err != nil {
+		t.Fatal("expected the paragraphs despite the formatting", err)
+	}
+	if len(d.Paragraphs) != 1 || d.Paragraphs[0].Text() != "Bold text" {
+		t.Errorf("expected one paragraph, got %+v", d.Paragraphs)
+	}
+}
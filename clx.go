@@ -3,11 +3,19 @@ package doc
 import (
 	"encoding/binary"
 	"errors"
+	"fmt"
 
 	"github.com/richardlehane/mscfb"
 )
 
 var (
+	// ErrNoCLX is returned for documents whose FIB doesn't point to a Clx,
+	// or to one without pieces, so there is no piece table locating the
+	// text, and whose fcMin and fcMac don't locate it either
+	ErrNoCLX = errors.New("document has no Clx")
+	// ErrMalformedCLX wraps the error found in a Clx that can't be parsed
+	ErrMalformedCLX = errors.New("malformed Clx")
+
 	errInvalidPrc  = errors.New("Invalid Prc structure")
 	errInvalidClx  = errors.New("expected last aCP value to equal fib.cpLength (2.8.35)")
 	errInvalidPcdt = errors.New("expected clxt to be equal 0x02")
@@ -48,33 +56,69 @@ func getClx(table *mscfb.File, fib *fib) (*clx, error) {
 
 	pcdtOffset, err := getPrcArrayEnd(b)
 	if err != nil {
-		return nil, err
+		return nil, malformedClx(err)
 	}
 
 	pcdt, err := getPcdt(b, pcdtOffset)
 	if err != nil {
-		return nil, err
+		return nil, malformedClx(err)
+	}
+	if len(pcdt.PlcPcd.aPcd) == 0 {
+		return nil, ErrNoCLX
 	}
 
 	if pcdt.PlcPcd.aCP[len(pcdt.PlcPcd.aCP)-1] != fib.fibRgLw.cpLength {
-		return nil, errInvalidClx
+		return nil, malformedClx(errInvalidClx)
 	}
 
 	return &clx{pcdt: *pcdt}, nil
 }
 
 func readClx(table *mscfb.File, fib *fib) ([]byte, error) {
-	b := make([]byte, fib.fibRgFcLcb.lcbClx)
-	_, err := table.ReadAt(b, int64(fib.fibRgFcLcb.fcClx))
+	b, err := readTableBytes(table, fib.fibRgFcLcb.fcClx, fib.fibRgFcLcb.lcbClx)
 	if err != nil {
 		return nil, err
 	}
+	if b == nil {
+		return nil, ErrNoCLX
+	}
 	return b, nil
 }
 
+// textRangeClx returns a piece table with a single piece spanning the main
+// document's text between the FIB's fcMin and fcMac, for simple documents
+// written without a Clx. The piece is compressed when the range holds one
+// byte per character and Unicode when it holds two; any other size, or a
+// range outside a WordDocument stream of size bytes, gives ErrNoCLX.
+func textRangeClx(fib *fib, size int64) (*clx, error) {
+	ccpText := fib.fibRgLw.ccpText
+	fcMin, fcMac := fib.base.fcMin, fib.base.fcMac
+	if fcMin < 0 || fcMac < fcMin || int64(fcMac) > size {
+		return nil, ErrNoCLX
+	}
+	var fc fcCompressed
+	switch fcMac - fcMin {
+	case ccpText:
+		fc = fcCompressed{fc: fcMin * 2, fCompressed: true}
+	case 2 * ccpText:
+		fc = fcCompressed{fc: fcMin}
+	default:
+		return nil, ErrNoCLX
+	}
+	return &clx{pcdt: pcdt{PlcPcd: plcPcd{aCP: []int{0, ccpText}, aPcd: []pcd{{fc: fc}}}}}, nil
+}
+
+// malformedClx wraps err so it matches both itself and ErrMalformedCLX
+func malformedClx(err error) error {
+	return fmt.Errorf("%w: %w", ErrMalformedCLX, err)
+}
+
 // read Pcdt from Clx (section 2.9.178)
 func getPcdt(clx []byte, pcdtOffset int) (*pcdt, error) {
 	const pcdSize = 8
+	if pcdtOffset+5 > len(clx) {
+		return nil, errInvalidArgument
+	}
 	if clx[pcdtOffset] != 0x02 { // clxt must be 0x02 or invalid
 		return nil, errInvalidPcdt
 	}
@@ -83,10 +127,20 @@ func getPcdt(clx []byte, pcdtOffset int) (*pcdt, error) {
 	numPcds := (lcb - 4) / (4 + pcdSize)                                     // see 2.2.2 in the spec for equation
 	numCps := numPcds + 1                                                    // always 1 more cp than pcds
 
+	// a CP array that isn't exactly one longer than the Pcd array leaves a
+	// remainder, and the PlcPcd must fit within the Clx
+	if lcb < 4 || (lcb-4)%(4+pcdSize) != 0 || plcPcdOffset+lcb > len(clx) {
+		return nil, errInvalidArgument
+	}
+
 	cps := make([]int, numCps)
 	for i := 0; i < numCps; i++ {
 		cpOffset := plcPcdOffset + i*4
 		cps[i] = int(binary.LittleEndian.Uint32(clx[cpOffset : cpOffset+4]))
+		if i > 0 && cps[i] < cps[i-1] {
+			// pieces can't have a negative length
+			return nil, errInvalidArgument
+		}
 	}
 
 	pcdStart := plcPcdOffset + 4*numCps
@@ -103,10 +157,16 @@ func getPrcArrayEnd(clx []byte) (int, error) {
 	prcOffset := 0
 	count := 0
 	for {
+		if prcOffset >= len(clx) {
+			return 0, errInvalidPrc
+		}
 		clxt := clx[prcOffset]
 		if clxt != 0x01 { // this is not a Prc, so exit
 			return prcOffset, nil
 		}
+		if prcOffset+3 > len(clx) {
+			return 0, errInvalidPrc
+		}
 		prcDataCbGrpprl := binary.LittleEndian.Uint16(clx[prcOffset+1 : prcOffset+3]) // skip the clxt and read 2 bytes
 		prcOffset += 1 + 2 + int(prcDataCbGrpprl)                                     // skip clxt, cbGrpprl, and GrpPrl
 
@@ -122,10 +182,10 @@ func parsePcd(pcdData []byte) *pcd {
 	return &pcd{fc: *parseFcCompressed(pcdData[2:6])}
 }
 
-// parse FcCompressed (section 2.9.73)
+// parse FcCompressed (section 2.9.73): fc in the low 30 bits, fCompressed
+// in bit 30 and the reserved r1 in bit 31. The fc of a compressed piece is
+// twice its byte offset, which writeText halves.
 func parseFcCompressed(fcData []byte) *fcCompressed {
-	fCompressed := fcData[3]&64 == 64        // check fcompressed value (second bit from lestmost of the last byte in fcdata)
-	fcData[3] = fcData[3] & 63               // clear the fcompressed value from data
-	fc := binary.LittleEndian.Uint32(fcData) // word doc generally uses little endian order (1.3.7)
-	return &fcCompressed{fc: int(fc), fCompressed: fCompressed}
+	v := binary.LittleEndian.Uint32(fcData) // word doc generally uses little endian order (1.3.7)
+	return &fcCompressed{fc: int(v & 0x3FFFFFFF), fCompressed: v&0x40000000 != 0}
 }
@@ -0,0 +1,146 @@
+package doc
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"testing"
+)
+
+func TestParseShortCPArray(t *testing.T) {
+	// a Pcdt holding one Pcd but only one CP
+	raw := []byte{0x02}
+	raw = binary.LittleEndian.AppendUint32(raw, 4+8)
+	raw = binary.LittleEndian.AppendUint32(raw, 0)
+	raw = append(raw, 0, 0, 0x00, 0x08, 0x00, 0x40, 0, 0)
+
+	b := testDoc{pieces: []testPiece{{text: "text\r", compressed: true}}, clx: raw}.build()
+	_, err := ParseDoc(bytes.NewReader(b))
+	if !errors.Is(err, errInvalidArgument) {
+		t.Errorf("expected errInvalidArgument, got %v", err)
+	}
+
+	pd := &parsedDoc{clx: &clx{pcdt: pcdt{PlcPcd: plcPcd{aCP: []int{0}, aPcd: []pcd{{}}}}}}
+	if err := writeText(pd, newTextWriter(Options{})); err != errInvalidArgument {
+		t.Errorf("expected errInvalidArgument, got %v", err)
+	}
+}
+
+func TestParseClxErrors(t *testing.T) {
+	pieces := []testPiece{{text: "text\r", compressed: true}}
+
+	// without a Clx, text of mixed widths can't be located from fcMin and
+	// fcMac
+	mixed := []testPiece{{text: "te", compressed: true}, {text: "xt\r"}}
+	noClx := testDoc{pieces: mixed, clx: []byte{}}.build()
+	if _, err := ParseDoc(bytes.NewReader(noClx)); !errors.Is(err, ErrNoCLX) {
+		t.Errorf("expected ErrNoCLX, got %v", err)
+	}
+
+	for name, raw := range map[string][]byte{
+		"truncated Prc": {0x01, 0x05},
+		"bad clxt":      {0x07, 0, 0, 0, 0},
+		"short PlcPcd":  {0x02, 0x40, 0, 0, 0, 0, 0, 0, 0},
+		"wrong last CP": append([]byte{0x02, 16, 0, 0, 0, 0, 0, 0, 0, 9, 0, 0, 0}, 0, 0, 0x00, 0x08, 0x00, 0x40, 0, 0),
+	} {
+		b := testDoc{pieces: pieces, clx: raw}.build()
+		_, err := ParseDoc(bytes.NewReader(b))
+		if !errors.Is(err, ErrMalformedCLX) || errors.Is(err, ErrNoCLX) {
+			t.Errorf("%s: expected ErrMalformedCLX, got %v", name, err)
+		}
+	}
+}
+
+func TestParseFcCompressed(t *testing.T) {
+	for _, test := range []struct {
+		raw        uint32
+		fc         int
+		compressed bool
+	}{
+		{0x00000400, 0x400, false},
+		{0x40000800, 0x800, true}, // byte offset 0x400
+		{0x40000801, 0x801, true},
+		{0x3FFFFFFF, 0x3FFFFFFF, false},
+		{0x7FFFFFFF, 0x3FFFFFFF, true},
+		{0x80000400, 0x400, false}, // r1 is ignored
+		{0xC0000800, 0x800, true},
+	} {
+		b := binary.LittleEndian.AppendUint32(nil, test.raw)
+		fc := parseFcCompressed(b)
+		if fc.fc != test.fc || fc.fCompressed != test.compressed {
+			t.Errorf("%#08x: expected fc %#x, compressed %v, got %#x, %v", test.raw, test.fc, test.compressed, fc.fc, fc.fCompressed)
+		}
+		if binary.LittleEndian.Uint32(b) != test.raw {
+			t.Errorf("%#08x: parsing modified the Pcd", test.raw)
+		}
+	}
+}
+
+func TestParseNonMonotonicCPs(t *testing.T) {
+	// CPs 0, 9, 5 for "text\r": the second piece runs backwards
+	raw := []byte{0x02, 28, 0, 0, 0, 0, 0, 0, 0, 9, 0, 0, 0, 5, 0, 0, 0}
+	for i := 0; i < 2; i++ {
+		raw = append(raw, 0, 0, 0x00, 0x08, 0x00, 0x40, 0, 0)
+	}
+	b := testDoc{pieces: []testPiece{{text: "text\r", compressed: true}}, clx: raw}.build()
+
+	for name, parse := range map[string]func() error{
+		"ParseDoc": func() error {
+			_, err := ParseDoc(bytes.NewReader(b))
+			return err
+		},
+		"ParseWithOffsets": func() error {
+			_, _, err := ParseWithOffsets(bytes.NewReader(b))
+			return err
+		},
+		"WalkRuns": func() error {
+			return WalkRuns(bytes.NewReader(b), func(RunEvent) error { return nil })
+		},
+	} {
+		if err := parse(); !errors.Is(err, ErrMalformedCLX) || !errors.Is(err, errInvalidArgument) {
+			t.Errorf("%s: expected ErrMalformedCLX, got %v", name, err)
+		}
+	}
+}
+
+func TestParseNoClxTextRange(t *testing.T) {
+	emptyPcdt := []byte{0x02, 4, 0, 0, 0, 0, 0, 0, 0}
+	for _, p := range []testPiece{{text: "plain text\r", compressed: true}, {text: "texte unicode\r"}} {
+		for _, raw := range [][]byte{{}, emptyPcdt} {
+			b := testDoc{pieces: []testPiece{p}, clx: raw}.build()
+			r, err := ParseDoc(bytes.NewReader(b))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if s := r.(*bytes.Buffer).String(); s != p.text {
+				t.Errorf("expected %q, got %q", p.text, s)
+			}
+		}
+	}
+}
+
+func TestParseTryAlternateTable(t *testing.T) {
+	entries := testDoc{pieces: []testPiece{{text: "recovered\r", compressed: true}}}.entries()
+	for i, e := range entries {
+		if e.name == "1Table" {
+			// the selected table's Clx is corrupt, 0Table holds a good copy
+			bad := append([]byte{}, e.data...)
+			bad[0] = 0x07
+			entries[i].data = bad
+			entries = append(entries, cfbEntry{name: "0Table", data: e.data})
+			break
+		}
+	}
+	b := buildCFB(entries)
+
+	if _, err := ParseDoc(bytes.NewReader(b)); !errors.Is(err, ErrMalformedCLX) {
+		t.Errorf("expected ErrMalformedCLX, got %v", err)
+	}
+	r, err := ParseDocWithOptions(bytes.NewReader(b), Options{TryAlternateTable: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := r.(*bytes.Buffer).String(); s != "recovered\r" {
+		t.Errorf("expected %q, got %q", "recovered\r", s)
+	}
+}
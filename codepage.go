@@ -0,0 +1,251 @@
+package doc
+
+import (
+	"encoding/binary"
+	"sync"
+	"unicode"
+	"unicode/utf16"
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/encoding/japanese"
+	"golang.org/x/text/encoding/korean"
+	"golang.org/x/text/encoding/simplifiedchinese"
+	"golang.org/x/text/encoding/traditionalchinese"
+	"golang.org/x/text/transform"
+)
+
+// codepageForLID returns the Windows ANSI code page conventionally used for
+// a language identifier ([MS-LCID]), or 1252 when it isn't known
+func codepageForLID(lid int) int {
+	switch lid {
+	case 0x0804, 0x1004: // Chinese (PRC, Singapore)
+		return 936
+	case 0x0404, 0x0C04, 0x1404: // Chinese (Taiwan, Hong Kong, Macao)
+		return 950
+	case 0x0C1A, 0x1C1A: // Serbian and Bosnian (Cyrillic)
+		return 1251
+	}
+
+	switch lid & 0x3FF { // primary language
+	case 0x11: // Japanese
+		return 932
+	case 0x12: // Korean
+		return 949
+	case 0x02, 0x19, 0x22, 0x23, 0x2F: // Bulgarian, Russian, Ukrainian, Belarusian, Macedonian
+		return 1251
+	case 0x05, 0x0E, 0x15, 0x18, 0x1A, 0x1B, 0x1C, 0x24: // Central European
+		return 1250
+	case 0x08: // Greek
+		return 1253
+	case 0x1F: // Turkish
+		return 1254
+	case 0x0D: // Hebrew
+		return 1255
+	case 0x01, 0x20, 0x29: // Arabic, Urdu, Farsi
+		return 1256
+	case 0x25, 0x26, 0x27: // Estonian, Latvian, Lithuanian
+		return 1257
+	case 0x2A: // Vietnamese
+		return 1258
+	case 0x1E: // Thai
+		return 874
+	}
+	return 1252
+}
+
+// encodingForCodepage returns the decoder for a Windows code page, or nil
+// if the package doesn't bundle it
+func encodingForCodepage(cp int) encoding.Encoding {
+	switch cp {
+	case 874:
+		return charmap.Windows874
+	case 932:
+		return japanese.ShiftJIS
+	case 936:
+		return simplifiedchinese.GBK
+	case 949:
+		return korean.EUCKR
+	case 950:
+		return traditionalchinese.Big5
+	case 1250:
+		return charmap.Windows1250
+	case 1251:
+		return charmap.Windows1251
+	case 1252:
+		return charmap.Windows1252
+	case 1253:
+		return charmap.Windows1253
+	case 1254:
+		return charmap.Windows1254
+	case 1255:
+		return charmap.Windows1255
+	case 1256:
+		return charmap.Windows1256
+	case 1257:
+		return charmap.Windows1257
+	case 1258:
+		return charmap.Windows1258
+	}
+	return nil
+}
+
+// decoderPools holds idle decoders for each bundled code page, so decoding
+// doesn't allocate a new decoder every time. The map itself is never
+// written after init.
+var decoderPools = func() map[int]*sync.Pool {
+	pools := make(map[int]*sync.Pool)
+	for _, cp := range []int{874, 932, 936, 949, 950, 1250, 1251, 1252, 1253, 1254, 1255, 1256, 1257, 1258} {
+		enc := encodingForCodepage(cp)
+		pools[cp] = &sync.Pool{New: func() any { return enc.NewDecoder() }}
+	}
+	return pools
+}()
+
+// getDecoder returns a reset decoder for a code page the package bundles,
+// or nil. Hand it back with putDecoder once done with it.
+func getDecoder(cp int) *encoding.Decoder {
+	p := decoderPools[cp]
+	if p == nil {
+		return nil
+	}
+	d := p.Get().(*encoding.Decoder)
+	d.Reset()
+	return d
+}
+
+// putDecoder returns a decoder from getDecoder for reuse
+func putDecoder(cp int, d *encoding.Decoder) {
+	decoderPools[cp].Put(d)
+}
+
+// scriptsForCodepage lists the scripts whose letters a code page exists to encode
+func scriptsForCodepage(cp int) []*unicode.RangeTable {
+	switch cp {
+	case 874:
+		return []*unicode.RangeTable{unicode.Thai}
+	case 932:
+		return []*unicode.RangeTable{unicode.Han, unicode.Hiragana, unicode.Katakana}
+	case 936, 950:
+		return []*unicode.RangeTable{unicode.Han}
+	case 949:
+		return []*unicode.RangeTable{unicode.Hangul, unicode.Han}
+	case 1251:
+		return []*unicode.RangeTable{unicode.Cyrillic}
+	case 1253:
+		return []*unicode.RangeTable{unicode.Greek}
+	case 1255:
+		return []*unicode.RangeTable{unicode.Hebrew}
+	case 1256:
+		return []*unicode.RangeTable{unicode.Arabic}
+	}
+	return []*unicode.RangeTable{unicode.Latin}
+}
+
+// isWordControl reports whether r is a control character Word stores in
+// text: tab, line feed, cell mark, breaks, paragraph mark and field marks
+func isWordControl(r rune) bool {
+	switch r {
+	case 0x07, 0x09, 0x0A, 0x0B, 0x0C, 0x0D, 0x13, 0x14, 0x15:
+		return true
+	}
+	return false
+}
+
+// scriptScore returns the fraction of runes in s belonging to scripts
+func scriptScore(s string, scripts []*unicode.RangeTable) float64 {
+	var total, matched int
+	for _, r := range s {
+		total++
+		if unicode.IsOneOf(scripts, r) {
+			matched++
+		}
+	}
+	if total == 0 {
+		return 0
+	}
+	return float64(matched) / float64(total)
+}
+
+// decodeCodepagePiece detects an uncompressed piece that actually holds
+// 8-bit code page bytes and returns it re-encoded as UTF-16LE so it can go
+// through translateUncompressedText, along with the offset in b of the
+// character each unit comes from, which its CP and FC follow. ok is false
+// for genuine UTF-16 pieces.
+//
+// The check only runs when the FIB's language maps to a code page other
+// than 1252, since Western text read as UTF-16 by mistake is rare and a
+// Latin score would misfire on CJK pieces in an English document. The piece
+// is then decoded both ways and the code page wins only when
+//   - decoding yields no U+FFFD and no control characters besides those
+//     Word uses in text (genuine UTF-16 nearly always decodes to NULs), and
+//   - at least half of the decoded runes belong to the code page's script,
+//     and more of them do than when the bytes are read as UTF-16.
+func decodeCodepagePiece(b []byte, fib *fib) (u []byte, from []int, ok bool) {
+	cp := codepageForLID(fib.base.lid)
+	if cp == 1252 || len(b) == 0 {
+		return nil, nil, false
+	}
+	dec := getDecoder(cp)
+	if dec == nil {
+		return nil, nil, false
+	}
+	decoded, starts := decodeChars(dec, b)
+	putDecoder(cp, dec)
+	for _, r := range decoded {
+		if r == utf8.RuneError || (r < 32 && !isWordControl(r)) {
+			return nil, nil, false
+		}
+	}
+
+	units := make([]uint16, len(b)/2)
+	for i := range units {
+		units[i] = binary.LittleEndian.Uint16(b[2*i:])
+	}
+	asUnicode := string(utf16.Decode(units))
+
+	scripts := scriptsForCodepage(cp)
+	score := scriptScore(string(decoded), scripts)
+	if score < 0.5 || score <= scriptScore(asUnicode, scripts) {
+		return nil, nil, false
+	}
+
+	for i, r := range decoded {
+		for _, unit := range utf16.Encode([]rune{r}) {
+			u = binary.LittleEndian.AppendUint16(u, unit)
+			from = append(from, starts[i])
+		}
+	}
+	return u, from, true
+}
+
+// decodeChars decodes b with dec a character at a time, returning the
+// runes and the offset in b at which each starts. Bytes that don't decode
+// give U+FFFD.
+func decodeChars(dec *encoding.Decoder, b []byte) (runes []rune, starts []int) {
+	var dst [16]byte
+	for pos := 0; pos < len(b); {
+		// feed the decoder one more byte at a time, so that it decodes a
+		// single character
+		n := 1
+		nDst, nSrc, err := dec.Transform(dst[:], b[pos:pos+n], pos+n == len(b))
+		for err == transform.ErrShortSrc && pos+n < len(b) {
+			n++
+			nDst, nSrc, err = dec.Transform(dst[:], b[pos:pos+n], pos+n == len(b))
+		}
+		if err != nil || nSrc == 0 {
+			runes = append(runes, utf8.RuneError)
+			starts = append(starts, pos)
+			dec.Reset()
+			pos++
+			continue
+		}
+		for _, r := range string(dst[:nDst]) {
+			runes = append(runes, r)
+			starts = append(starts, pos)
+		}
+		pos += nSrc
+	}
+	return runes, starts
+}
@@ -0,0 +1,111 @@
+package doc
+
+import (
+	"bytes"
+	"slices"
+	"testing"
+
+	"golang.org/x/text/encoding/simplifiedchinese"
+)
+
+func TestParseCodepageInUncompressedPiece(t *testing.T) {
+	gbk, err := simplifiedchinese.GBK.NewEncoder().Bytes([]byte("中文测试文档。\r"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	gbk = append(gbk, ' ') // pieces flagged uncompressed hold an even number of bytes
+
+	b := testDoc{lid: 0x0804, pieces: []testPiece{
+		{raw: gbk},
+		{text: "真正的统一码\r"},
+	}}.build()
+	buf, err := ParseDoc(bytes.NewReader(b))
+	if err != nil {
+		t.Fatal("expected successful parse", err)
+	}
+	if s := buf.(*bytes.Buffer).String(); s != "中文测试文档。\r 真正的统一码\r" {
+		t.Errorf("expected correct value |%s|", s)
+	}
+}
+
+func TestParseCodepageInUncompressedPieceOffsets(t *testing.T) {
+	// the piece holds 6 UTF-16 units but decodes to 7 characters, two of
+	// them in its first unit
+	gbk, err := simplifiedchinese.GBK.NewEncoder().Bytes([]byte("a中文测试。\r"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	b := testDoc{lid: 0x0804, pieces: []testPiece{{raw: gbk}}}.build()
+
+	text, offsets, err := ParseWithOffsets(bytes.NewReader(b))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if text != "a中文测试。\r" || !slices.Equal(offsets, []int{0, 0, 1, 2, 3, 4, 5}) {
+		t.Errorf("expected CPs of the piece's own units, got %q %v", text, offsets)
+	}
+
+	// the paragraph mark is still found at the document's last CP
+	buf, err := ParseDocWithOptions(bytes.NewReader(b), Options{DropFinalMark: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := buf.(*bytes.Buffer).String(); s != "a中文测试。" {
+		t.Errorf("expected the final mark dropped, got %q", s)
+	}
+}
+
+func TestCodepageForLID(t *testing.T) {
+	for lid, cp := range map[int]int{0x0409: 1252, 0x0804: 936, 0x0404: 950, 0x0411: 932, 0x0419: 1251, 0x0415: 1250} {
+		if actual := codepageForLID(lid); actual != cp {
+			t.Errorf("expected code page %d for lid 0x%04X, got %d", cp, lid, actual)
+		}
+	}
+}
+
+func TestParseCyrillicControlRange(t *testing.T) {
+	// CP1251 assigns letters to bytes CP1252 uses for punctuation
+	raw := []byte("\x80\x81\x8a\x8c \x90\x9a\x9c \xa8\xb8\r")
+	const want = "ЂЃЉЊ ђљњ Ёё\r"
+
+	for name, tc := range map[string]struct {
+		doc  testDoc
+		opts Options
+	}{
+		"lid":    {testDoc{lid: 0x0419, noExtChar: true}, Options{}},
+		"option": {testDoc{}, Options{Codepage: 1251}},
+	} {
+		tc.doc.pieces = []testPiece{{raw: raw, compressed: true}}
+		buf, err := ParseDocWithOptions(bytes.NewReader(tc.doc.build()), tc.opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if s := buf.(*bytes.Buffer).String(); s != want {
+			t.Errorf("%s: expected %q, got %q", name, want, s)
+		}
+	}
+
+	// without either, the bytes stay CP1252
+	buf, err := ParseDoc(bytes.NewReader(testDoc{pieces: []testPiece{{raw: raw, compressed: true}}}.build()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := buf.(*bytes.Buffer).String(); s[:len("€")] != "€" {
+		t.Errorf("expected CP1252 without a code page, got %q", s)
+	}
+}
+
+func BenchmarkParseGBK(b *testing.B) {
+	gbk, err := simplifiedchinese.GBK.NewEncoder().Bytes([]byte("中文测试文档，这是一段较长的中文文本。\r"))
+	if err != nil {
+		b.Fatal(err)
+	}
+	doc := testDoc{pieces: []testPiece{{raw: bytes.Repeat(gbk, 500), compressed: true}}}.build()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := ParseDoc(bytes.NewReader(doc)); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
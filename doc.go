@@ -4,15 +4,33 @@ import (
 	"bytes"
 	"encoding/binary"
 	"errors"
+	"fmt"
 	"io"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf16"
 	"unicode/utf8"
 
 	"github.com/mattetti/filebuffer"
 	"github.com/richardlehane/mscfb"
-	"golang.org/x/text/encoding/simplifiedchinese"
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/transform"
 )
 
 var (
+	// ErrReadTimeout is returned when Options.ReadTimeout elapses before the
+	// input has been read into memory
+	ErrReadTimeout = errors.New("timed out reading document")
+	// ErrNotOLE2 is returned for input that isn't an OLE2 compound file,
+	// such as a .docx (which is a ZIP archive)
+	ErrNotOLE2 = errors.New("not an OLE2 compound file")
+	// ErrClosed is returned by reads from a ParseDocReadCloser result
+	// after Close
+	ErrClosed = errors.New("reader already closed")
+
 	errTable           = errors.New("cannot find table stream")
 	errDocEmpty        = errors.New("WordDocument not found")
 	errDocShort        = errors.New("wordDoc block too short")
@@ -26,44 +44,225 @@ type allReader interface {
 }
 
 func wrapError(e error) error {
-	return errors.New("Error processing file: " + e.Error())
+	return fmt.Errorf("Error processing file: %w", e)
 }
 
 // ParseDoc converts a standard io.Reader from a Microsoft Word
 // .doc binary file and returns a reader (actually a bytes.Buffer)
-// which will output the plain text found in the .doc file
+// which will output the plain text found in the .doc file. A valid
+// document without text gives an empty, non-nil reader and a nil error;
+// the error is non-nil whenever the reader is nil.
 func ParseDoc(r io.Reader) (io.Reader, error) {
-	ra, ok := r.(io.ReaderAt)
-	if !ok {
-		ra, _, err := toMemoryBuffer(r)
-		if err != nil {
-			return nil, wrapError(err)
-		}
-		defer ra.Close()
+	return ParseDocWithOptions(r, Options{})
+}
+
+// ParseDocWithOptions is like ParseDoc but reads and translates the
+// document according to opts
+func ParseDocWithOptions(r io.Reader, opts Options) (io.Reader, error) {
+	ra, release, err := readerAt(r, opts)
+	if err != nil {
+		return nil, wrapError(err)
 	}
+	defer release()
 
-	d, err := mscfb.New(ra)
+	pd, err := openDoc(ra, opts)
 	if err != nil {
 		return nil, wrapError(err)
 	}
 
+	return getText(pd, opts)
+}
+
+// ParseReaderAt is like ParseDoc for a .doc file of size bytes in ra, such
+// as a file in remote storage read by range requests. The file is read in
+// place, only where its structures and text are, rather than copied into
+// memory.
+func ParseReaderAt(ra io.ReaderAt, size int64) (io.Reader, error) {
+	return ParseDoc(io.NewSectionReader(ra, 0, size))
+}
+
+// ParseDocReadCloser is like ParseDoc but returns an io.ReadCloser whose
+// Close releases the extracted text. Close may be called more than once;
+// reads after it fail with ErrClosed. The reader also implements
+// io.WriterTo, so io.Copy writes the text without an intermediate buffer.
+func ParseDocReadCloser(r io.Reader) (io.ReadCloser, error) {
+	text, err := ParseDoc(r)
+	if err != nil {
+		return nil, err
+	}
+	return &textReadCloser{buf: text.(*bytes.Buffer)}, nil
+}
+
+// textReadCloser is the io.ReadCloser returned by ParseDocReadCloser
+type textReadCloser struct {
+	buf *bytes.Buffer // nil once closed
+}
+
+func (t *textReadCloser) Read(p []byte) (int, error) {
+	if t.buf == nil {
+		return 0, ErrClosed
+	}
+	return t.buf.Read(p)
+}
+
+func (t *textReadCloser) WriteTo(w io.Writer) (int64, error) {
+	if t.buf == nil {
+		return 0, ErrClosed
+	}
+	return t.buf.WriteTo(w)
+}
+
+func (t *textReadCloser) Close() error {
+	if t.buf != nil {
+		t.buf.Reset()
+		t.buf = nil
+	}
+	return nil
+}
+
+// Validate checks that r holds a .doc file this package can parse: an OLE2
+// compound file with a WordDocument stream, a usable FIB and the table
+// stream the FIB refers to. It doesn't extract any text. It returns nil or
+// one of the sentinel errors describing what is missing.
+func Validate(r io.Reader) error {
+	ra, release, err := readerAt(r, Options{})
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	d, err := newCFB(ra)
+	if err != nil {
+		return ErrNotOLE2
+	}
+	if !hasStreams(d) {
+		return errDocEmpty
+	}
+
 	wordDoc, table0, table1 := getWordDocAndTables(d)
 	fib, err := getFib(wordDoc)
+	if err != nil {
+		return err
+	}
+
+	if getActiveTable(table0, table1, fib) == nil {
+		return errTable
+	}
+	return nil
+}
+
+// RawWordDocument returns the contents of the WordDocument stream of the
+// .doc file in r, for inspection with other tools
+func RawWordDocument(r io.Reader) ([]byte, error) {
+	ra, release, err := readerAt(r, Options{})
 	if err != nil {
 		return nil, wrapError(err)
 	}
+	defer release()
+
+	d, err := newCFB(ra)
+	if err != nil {
+		return nil, wrapError(err)
+	}
+	wordDoc, _, _ := getWordDocAndTables(d)
+	if wordDoc == nil {
+		return nil, wrapError(errDocEmpty)
+	}
+	b := make([]byte, wordDoc.Size)
+	if _, err := wordDoc.ReadAt(b, 0); err != nil {
+		return nil, wrapError(err)
+	}
+	return b, nil
+}
+
+// parsedDoc holds the streams and structures every entry point needs
+type parsedDoc struct {
+	size    int64 // of the input, -1 if unknown
+	cfb     *mscfb.Reader
+	wordDoc *mscfb.File
+	table   *mscfb.File
+	data    *mscfb.File // nil if the document has no Data stream
+	path    []string    // of the storage holding the document, nil for the root
+	fib     *fib
+	clx     *clx
+}
+
+// openDoc reads the compound file in ra and parses its FIB and piece table
+func openDoc(ra io.ReaderAt, opts Options) (*parsedDoc, error) {
+	d, err := newCFB(ra)
+	if err != nil {
+		return nil, err
+	}
+	if !hasStreams(d) {
+		return nil, errDocEmpty
+	}
+	pd, err := openStorage(d, nil, opts)
+	if err != nil {
+		return nil, err
+	}
+	pd.size = inputSize(ra)
+	return pd, nil
+}
+
+// hasStreams reports whether the compound file holds any stream at all. A
+// malformed container may hold no more than its root storage.
+func hasStreams(d *mscfb.Reader) bool {
+	for _, f := range d.File {
+		if !f.FileInfo().IsDir() {
+			return true
+		}
+	}
+	return false
+}
+
+// openStorage parses the FIB and piece table of the Word document held in
+// the storage at path (nil for the root)
+func openStorage(d *mscfb.Reader, path []string, opts Options) (*parsedDoc, error) {
+	wordDoc, table0, table1 := getWordDocAndTablesAt(d, path, opts.StreamNameNormalizer)
+	fib, err := getFib(wordDoc)
+	if err != nil {
+		return nil, err
+	}
 
 	table := getActiveTable(table0, table1, fib)
 	if table == nil {
-		return nil, wrapError(errTable)
+		return nil, errTable
 	}
 
 	clx, err := getClx(table, fib)
+	if errors.Is(err, ErrNoCLX) {
+		clx, err = textRangeClx(fib, wordDoc.Size)
+	}
+	if err != nil && opts.TryAlternateTable {
+		alternate := table0
+		if table == table0 {
+			alternate = table1
+		}
+		if alternate != nil {
+			if altClx, altErr := getClx(alternate, fib); altErr == nil {
+				table, clx, err = alternate, altClx, nil
+			}
+		}
+	}
 	if err != nil {
-		return nil, wrapError(err)
+		return nil, err
 	}
 
-	return getText(wordDoc, clx, fib)
+	data := getStreamAt(d, path, "Data")
+	return &parsedDoc{cfb: d, wordDoc: wordDoc, table: table, data: data, path: path, fib: fib, clx: clx}, nil
+}
+
+// readerAt returns r as an io.ReaderAt, buffering it in memory when it is
+// not one already. The returned func releases the buffer.
+func readerAt(r io.Reader, opts Options) (io.ReaderAt, func(), error) {
+	if ra, ok := r.(io.ReaderAt); ok {
+		return ra, func() {}, nil
+	}
+	fb, _, err := toMemoryBufferTimeout(r, opts.ReadTimeout)
+	if err != nil {
+		return nil, nil, err
+	}
+	return fb, func() { fb.Close() }, nil
 }
 
 func toMemoryBuffer(r io.Reader) (allReader, int64, error) {
@@ -76,223 +275,822 @@ func toMemoryBuffer(r io.Reader) (allReader, int64, error) {
 	return fb, size, nil
 }
 
-func getText(wordDoc *mscfb.File, clx *clx, fib *fib) (io.Reader, error) {
-	var buf bytes.Buffer
-	for i := 0; i < len(clx.pcdt.PlcPcd.aPcd); i++ {
+// toMemoryBufferTimeout runs toMemoryBuffer in a goroutine and gives up with
+// ErrReadTimeout once timeout has elapsed. The goroutine keeps draining r in
+// the background until r returns, as there is no way to interrupt a Read.
+func toMemoryBufferTimeout(r io.Reader, timeout time.Duration) (allReader, int64, error) {
+	if timeout <= 0 {
+		return toMemoryBuffer(r)
+	}
+
+	type result struct {
+		fb   allReader
+		size int64
+		err  error
+	}
+	done := make(chan result, 1)
+	go func() {
+		fb, size, err := toMemoryBuffer(r)
+		done <- result{fb: fb, size: size, err: err}
+	}()
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case res := <-done:
+		return res.fb, res.size, res.err
+	case <-timer.C:
+		return nil, 0, ErrReadTimeout
+	}
+}
+
+// textWriter collects translated text across pieces and stops accepting
+// characters once maxChars runes have been written. Field state lives here
+// too, as a field may start in one piece and end in another.
+type textWriter struct {
+	buf            *bytes.Buffer
+	maxChars       int
+	chars          int
+	fields         []bool       // open fields, innermost last; true until the separator
+	fieldCodes     int          // number of open fields still in their instructions
+	props          charProps    // of the text being translated
+	lid            uint16       // the document's language
+	pieceCP        int          // CP of the first character being translated
+	pieceFC        int          // and its offset in the WordDocument stream
+	cpFrom, cpTo   int          // range of CPs to translate, the main document if cpTo is 0
+	cp             int          // CP of the character being translated
+	trackOffsets   bool         // record the CP of each character written
+	offsets        []int        // CPs of the characters in buf, when tracked
+	sectionBreak   string       // written in place of section marks
+	sectionMarks   map[int]bool // CPs of section marks
+	columnBreak    string
+	footnoteMarker string
+	objectMarker   string
+	objects        map[int]string
+	footnotes      int   // footnote references seen
+	pageBreaks     []int // offsets in buf of page and section breaks
+	codepage       int
+	decoder        *encoding.Decoder
+	pending        []byte // lead bytes waiting for their trail byte
+	pendingFC      int    // offset of the first pending byte
+	onDecodeWarn   func(offset int, b byte)
+	replaceInvalid bool
+	placeholder    string
+	highSurrogate  rune // waiting for its low surrogate, 0 if none
+	includeHidden  bool
+	lineEnding     string
+	applyCase      bool
+	dropFinalMark  bool
+	finalMark      int  // CP of the document's last paragraph mark, -1 unless dropped
+	plainSpaces    bool // write typographic spaces as ' '
+	pieceDelimiter string
+	delimitPiece   bool // write pieceDelimiter before the next character
+	repairOffsets  bool
+	detectMismatch bool
+	onProgress     func(readBytes, totalBytes int64)
+	onTruncated    func(cp int)
+	asciiOnly      bool // translate with translateASCII
+	cellSeparator  string
+	read           int64                // bytes of pieces read, for onProgress
+	walk           func(RunEvent) error // receives text as it is decoded, if set
+	walkErr        error
+	inParagraph    bool
+	papx           []papxRun     // paragraph properties, read when needed
+	styles         []style       // of the stylesheet, when walking
+	paraStyle      style         // of the paragraph ending, when walking
+	tables         *tableBuilder // collects table cells, if set
+	collectLinks   bool          // collect hyperlinks into links
+	inlineLinks    bool          // write hyperlink targets after their text
+	instrs         []*fieldInstr // of open fields, innermost last
+	lists          listCounts    // numbers reached by auto-numbering fields
+	docLists       listDefs      // lists the document names, for LISTNUM fields
+	links          []Hyperlink
+	marks          []paraProps // of paragraph marks in buf, when walking
+}
+
+func newTextWriter(opts Options) *textWriter {
+	return &textWriter{
+		buf:            &bytes.Buffer{},
+		maxChars:       opts.MaxChars,
+		sectionBreak:   opts.SectionBreak,
+		columnBreak:    opts.ColumnBreak,
+		footnoteMarker: opts.FootnoteMarker,
+		objectMarker:   opts.ObjectMarker,
+		codepage:       opts.Codepage,
+		decoder:        opts.CustomDecoder,
+		replaceInvalid: opts.ReplaceInvalid,
+		placeholder:    opts.DropPlaceholder,
+		includeHidden:  opts.IncludeHiddenText,
+		lineEnding:     string(opts.LineEnding),
+		applyCase:      opts.ApplyCaseFormatting,
+		dropFinalMark:  opts.DropFinalMark,
+		finalMark:      -1,
+		plainSpaces:    opts.NormalizeSpaces,
+		pieceDelimiter: opts.PieceDelimiter,
+		repairOffsets:  opts.RepairOffsets,
+		detectMismatch: opts.DetectCompressionMismatch,
+		onProgress:     opts.OnProgress,
+		onTruncated:    opts.OnTruncated,
+		cellSeparator:  opts.CellSeparator,
+		inlineLinks:    opts.LinkFormat == LinkTextURL,
+		onDecodeWarn:   opts.OnDecodeWarning,
+	}
+}
+
+// full reports whether the MaxChars limit has been reached, or the walk
+// has been halted
+func (w *textWriter) full() bool {
+	return w.walkErr != nil || (w.maxChars > 0 && w.chars >= w.maxChars)
+}
+
+// upper reports whether the text being translated is shown in capitals
+func (w *textWriter) upper() bool {
+	return w.applyCase && w.props.caps
+}
+
+// write appends the UTF-8 encoding of a single character
+func (w *textWriter) write(char []byte) {
+	if w.full() {
+		return
+	}
+	if w.upper() {
+		char = bytes.Map(unicode.ToUpper, char)
+	}
+	if w.plainSpaces {
+		char = bytes.Map(plainSpace, char)
+	}
+	w.delimit()
+	w.buf.Write(char)
+	w.chars++
+	if w.trackOffsets {
+		w.offsets = append(w.offsets, w.cp)
+	}
+}
+
+// delimit writes the PieceDelimiter due before the first character of a
+// piece. It isn't document text, so it doesn't count toward MaxChars, and
+// maps to the CP of the character it precedes.
+func (w *textWriter) delimit() {
+	if !w.delimitPiece {
+		return
+	}
+	w.delimitPiece = false
+	w.buf.WriteString(w.pieceDelimiter)
+	if w.trackOffsets {
+		for range utf8.RuneCountInString(w.pieceDelimiter) {
+			w.offsets = append(w.offsets, w.cp)
+		}
+	}
+}
+
+// writeASCII appends printable ASCII bytes, each of which is one character,
+// up to the MaxChars limit
+func (w *textWriter) writeASCII(b []byte) {
+	if w.maxChars > 0 && len(b) > w.maxChars-w.chars {
+		b = b[:w.maxChars-w.chars]
+	}
+	if len(b) > 0 {
+		w.delimit()
+	}
+	if w.upper() {
+		b = bytes.ToUpper(b)
+	}
+	w.buf.Write(b)
+	w.chars += len(b)
+	if w.trackOffsets {
+		for i := range b {
+			w.offsets = append(w.offsets, w.cp+i)
+		}
+	}
+}
+
+func (w *textWriter) writeRune(r rune) {
+	utf8Bytes := make([]byte, 4)
+	n := utf8.EncodeRune(utf8Bytes, r)
+	w.write(utf8Bytes[:n])
+}
+
+// writeInvalid handles a 16-bit unit that isn't valid Unicode, writing
+// the placeholder, or U+FFFD if replaceInvalid is set
+func (w *textWriter) writeInvalid() {
+	switch {
+	case w.placeholder != "":
+		w.writeString(w.placeholder)
+	case w.replaceInvalid:
+		w.writeRune(utf8.RuneError)
+	}
+}
+
+// writeUndecodable writes decoded compressed text, with the placeholder,
+// if set, in place of each U+FFFD standing for bytes that couldn't be
+// decoded
+func (w *textWriter) writeUndecodable(s string) {
+	if w.placeholder != "" {
+		s = strings.ReplaceAll(s, string(utf8.RuneError), w.placeholder)
+	}
+	w.writeString(s)
+}
+
+// flushSurrogate handles a high surrogate that wasn't followed by a low one
+func (w *textWriter) flushSurrogate() {
+	if w.highSurrogate != 0 {
+		w.highSurrogate = 0
+		w.writeInvalid()
+	}
+}
+
+func (w *textWriter) writeByte(char byte) {
+	w.write([]byte{char})
+}
+
+// writeString appends s one character at a time
+func (w *textWriter) writeString(s string) {
+	for _, r := range s {
+		w.write([]byte(string(r)))
+	}
+}
+
+// writeDecoded feeds the compressed byte at fc to the custom decoder,
+// holding lead bytes back until the decoder has a complete character
+func (w *textWriter) writeDecoded(char byte, fc int) {
+	if len(w.pending) == 0 {
+		w.pendingFC = fc
+	}
+	w.pending = append(w.pending, char)
+	w.cp -= len(w.pending) - 1 // the character starts at the first pending byte
+	out := make([]byte, 16)
+	nDst, nSrc, err := w.decoder.Transform(out, w.pending, false)
+	if err != nil && err != transform.ErrShortSrc {
+		nDst, nSrc = utf8.EncodeRune(out, utf8.RuneError), len(w.pending)
+	}
+	if bytes.ContainsRune(out[:nDst], utf8.RuneError) {
+		w.decodeWarning(w.pendingFC, w.pending[0])
+	}
+	w.writeUndecodable(string(out[:nDst]))
+	w.pending = w.pending[nSrc:]
+}
+
+// flushDecoder writes out lead bytes that never got a trail byte
+func (w *textWriter) flushDecoder() {
+	if len(w.pending) == 0 {
+		return
+	}
+	out, _, err := transform.Bytes(w.decoder, w.pending)
+	if err != nil {
+		out = []byte(string(utf8.RuneError))
+	}
+	if bytes.ContainsRune(out, utf8.RuneError) {
+		w.decodeWarning(w.pendingFC, w.pending[0])
+	}
+	w.writeUndecodable(string(out))
+	w.pending = w.pending[:0]
+}
+
+// decodeWarning reports a byte at fc that couldn't be decoded
+func (w *textWriter) decodeWarning(fc int, b byte) {
+	if w.onDecodeWarn != nil {
+		w.onDecodeWarn(fc, b)
+	}
+}
+
+// field tracks a field character (section 2.8.25), reporting whether char
+// was one. Fields nest, so text is only written when no enclosing field is
+// in its instructions; a field inside another field's result shows its own
+// result. Unmatched separators and ends are ignored.
+func (w *textWriter) field(char uint16) bool {
+	if char != 0x13 && char != 0x14 && char != 0x15 {
+		return false
+	}
+	w.emitText()
+
+	n := len(w.fields)
+	switch char {
+	case 0x13: // begin
+		w.fields = append(w.fields, true)
+		w.fieldCodes++
+		w.beginInstr()
+		w.emitEvent(FieldStart)
+	case 0x14: // separate
+		if n > 0 && w.fields[n-1] {
+			w.fields[n-1] = false
+			w.fieldCodes--
+			w.separateInstr()
+			w.emitEvent(FieldSeparator)
+		}
+	case 0x15: // end
+		if n > 0 {
+			if w.fields[n-1] {
+				w.fieldCodes--
+			}
+			w.endInstr(!w.fields[n-1])
+			w.fields = w.fields[:n-1]
+			w.emitEvent(FieldEnd)
+		}
+	}
+	return true
+}
+
+// inFieldCode reports whether text is part of field instructions
+func (w *textWriter) inFieldCode() bool {
+	return w.fieldCodes > 0
+}
+
+// paragraphMark writes the paragraph mark found at fc
+func (w *textWriter) paragraphMark(fc int) {
+	if w.cp == w.finalMark {
+		w.tableParagraph(fc)
+		return
+	}
+	if w.lineEnding != "" {
+		w.writeString(w.lineEnding)
+		w.tableParagraph(fc)
+		return
+	}
+	chars := w.chars
+	w.writeByte('\r')
+	w.tableParagraph(fc)
+	if w.walk != nil && w.chars > chars {
+		w.marks = append(w.marks, paraPropsAt(w.papx, fc))
+		w.emitText() // so a walk halted at the paragraph's end stops here
+	}
+}
+
+// writeControl handles a control character found at cp. Field characters
+// (0x13-0x15) never get here. Other special characters, such as pictures
+// (0x01, unless they are OLE objects and ObjectMarker is set), footnote
+// separators (0x03, 0x04), drawn objects (0x08) and optional hyphens
+// (0x1F), are dropped.
+func (w *textWriter) writeControl(char uint16, cp int) {
+	switch {
+	case char == 0x02: // footnote or endnote reference
+		w.footnotes++
+		if w.footnoteMarker != "" {
+			w.writeString(strings.ReplaceAll(w.footnoteMarker, "%d", strconv.Itoa(w.footnotes)))
+		}
+	case char == 0x01: // picture or OLE object
+		if w.objectMarker != "" && w.props.ole && w.props.hasPic {
+			w.writeString(strings.ReplaceAll(w.objectMarker, "%s", w.objectName(w.props.picLocation)))
+		}
+	case char == 0x05: // annotation reference, dropped
+	case char == 0x0C:
+		w.pageBreaks = append(w.pageBreaks, w.buf.Len())
+		if w.sectionMarks[cp] {
+			w.writeString(w.sectionBreak)
+		}
+	case char == 0x0B:
+		w.writeString(w.lineEnding)
+	case char == 0x0E:
+		w.writeString(w.columnBreak)
+	case char == 0x1E: // non-breaking hyphen
+		w.writeRune(0x2011)
+	}
+}
+
+func getText(pd *parsedDoc, opts Options) (io.Reader, error) {
+	w := newTextWriter(opts)
+	if err := writeText(pd, w); err != nil {
+		return nil, err
+	}
+	w.finishText(opts)
+	return w.buf, nil
+}
+
+// finishText applies the options that rewrite the text as a whole once
+// every piece has been translated
+func (w *textWriter) finishText(opts Options) {
+	if opts.CollapseWhitespace {
+		w.collapseWhitespace()
+	}
+	if opts.TrimTrailing {
+		w.trimTrailing()
+	}
+}
+
+// writeText translates the pieces into w in CP order, recording in w.runs
+// where each piece's text, split into runs of the same character
+// properties, ends in w.buf
+func writeText(pd *parsedDoc, w *textWriter) error {
+	clx := pd.clx
+	if len(clx.pcdt.PlcPcd.aCP) != len(clx.pcdt.PlcPcd.aPcd)+1 {
+		return errInvalidArgument
+	}
+	w.lid = uint16(pd.fib.base.lid)
+
+	if w.sectionBreak != "" {
+		marks, err := getSectionMarks(pd.table, pd.fib)
+		if err != nil {
+			return err
+		}
+		w.sectionMarks = marks
+	}
+
+	defer w.startDecoding(pd.fib)()
+
+	// formatting only refines the text, so properties that can't be read
+	// are left at their defaults rather than failing the extraction
+	chpxRuns, err := getChpxRuns(pd.wordDoc, pd.table, pd.fib)
+	if err != nil {
+		chpxRuns = nil
+	}
+	if w.walk != nil || w.tables != nil || w.cellSeparator != "" {
+		if w.papx, err = getPapxRuns(pd.wordDoc, pd.table, pd.fib); err != nil {
+			w.papx = nil
+		}
+	}
+	if w.walk != nil {
+		if w.styles, err = getStyles(pd.table, pd.fib); err != nil {
+			w.styles = nil
+		}
+	}
+	if w.docLists, err = getListDefs(pd.table, pd.fib); err != nil {
+		w.docLists = nil
+	}
+	if w.objectMarker != "" {
+		w.objects = getObjectProgIDs(pd.cfb, pd.path)
+	}
+	cpFrom, cpTo := w.cpFrom, w.cpTo
+	if cpTo == 0 {
+		// footnotes, headers, comments and the other subdocuments follow
+		// the main document's ccpText characters
+		cpTo = pd.fib.fibRgLw.ccpText
+		if w.dropFinalMark {
+			w.finalMark = cpTo - 1
+		}
+	}
+	for i := 0; i < len(clx.pcdt.PlcPcd.aPcd) && !w.full(); i++ {
 		pcd := clx.pcdt.PlcPcd.aPcd[i]
 		cp := clx.pcdt.PlcPcd.aCP[i]
 		cpNext := clx.pcdt.PlcPcd.aCP[i+1]
 
-		var start, end int
-		if pcd.fc.fCompressed {
+		var start, end, width int
+		compressed := pcd.fc.fCompressed
+		if compressed {
 			start = pcd.fc.fc / 2
-			end = start + (cpNext - cp)
 		} else {
 			start = pcd.fc.fc
-			end = start + 2*(cpNext-cp)
+		}
+		if w.detectMismatch && cpNext > cp {
+			mismatch, err := compressionMismatch(pd.wordDoc, start, cpNext-cp, compressed)
+			if err != nil {
+				return err
+			}
+			compressed = compressed != mismatch
+		}
+		if compressed {
+			width = 1
+		} else {
+			width = 2
+		}
+		end = start + width*(cpNext-cp)
+		truncated := cpNext >= cp && int64(end) > pd.wordDoc.Size && isTruncated(clx, i, pd.wordDoc.Size)
+		if truncated {
+			// the stream ends in this piece and later pieces are cut off
+			// too: read what is left of it and stop
+			n := max(int(pd.wordDoc.Size)-start, 0) / width
+			cpNext, end = cp+n, start+width*n
+			if w.onTruncated != nil {
+				w.onTruncated(cpNext)
+			}
+			if n == 0 {
+				break
+			}
+		}
+		if cpNext < cp || int64(end) > pd.wordDoc.Size {
+			return errInvalidArgument
+		}
+		if w.repairOffsets {
+			shift, err := offsetShift(pd.wordDoc, start, end, compressed)
+			if err != nil {
+				return err
+			}
+			start += shift
+			end += shift
+		}
+		if cpNext <= cpFrom || cp >= cpTo {
+			continue
+		}
+		if cp < cpFrom {
+			start += width * (cpFrom - cp)
+			cp = cpFrom
+		}
+		if cpNext > cpTo {
+			end -= width * (cpNext - cpTo)
 		}
 
 		b := make([]byte, end-start)
-		_, err := wordDoc.ReadAt(b, int64(start))
+		_, err := pd.wordDoc.ReadAt(b, int64(start))
 		if err != nil {
-			return nil, err
+			return err
 		}
 
-		err = translateText(b, &buf, pcd.fc.fCompressed, fib)
-		if err != nil {
-			return nil, err
+		// the delimiter goes between pieces that write text, so it waits
+		// for this piece's first character
+		w.delimitPiece = w.pieceDelimiter != "" && w.chars > 0
+		for _, run := range splitPiece(chpxRuns, start, end, compressed) {
+			if run.props.hidden && !w.includeHidden {
+				continue
+			}
+			w.pieceCP = cp + run.from/width
+			w.pieceFC = start + run.from
+			w.props = run.props
+			err = translateText(b[run.from:run.to], w, compressed, pd.fib)
+			if err != nil {
+				return err
+			}
+			w.emitText()
+		}
+		if w.onProgress != nil {
+			w.read += int64(end - start)
+			w.onProgress(w.read, pd.size)
+		}
+		if truncated {
+			break
+		}
+	}
+	return nil
+}
+
+// startDecoding picks the decoder for high bytes of compressed text: the
+// CustomDecoder, else the Codepage option, else the code page of the
+// document's language when fExtChar is clear. The returned func writes
+// what is still pending and releases a pooled decoder.
+func (w *textWriter) startDecoding(f *fib) func() {
+	cp := 0
+	if w.decoder == nil {
+		cp = w.codepage
+		if cp == 0 && !f.base.fExtChar {
+			// compressed text is in the code page of the document's language
+			cp = codepageForLID(f.base.lid)
 		}
+		if cp == 1252 {
+			cp = 0
+		}
+		if cp != 0 {
+			w.decoder = getDecoder(cp)
+		}
+	}
+	if w.decoder != nil {
+		w.decoder.Reset()
+	}
+	return func() {
+		if w.decoder != nil {
+			w.flushDecoder()
+			if cp != 0 {
+				putDecoder(cp, w.decoder)
+				w.decoder = nil
+			}
+		}
+		w.flushSurrogate()
 	}
-	return &buf, nil
 }
 
-func translateText(b []byte, buf *bytes.Buffer, fCompressed bool, fib *fib) error {
+// isTruncated reports whether the piece at index i of clx and every piece
+// after it run past the end of a WordDocument stream of size bytes, as
+// when the stream was cut short. A piece past the end followed by one
+// that fits is corrupt instead.
+func isTruncated(clx *clx, i int, size int64) bool {
+	plc := clx.pcdt.PlcPcd
+	for ; i < len(plc.aPcd); i++ {
+		fc, n := plc.aPcd[i].fc, plc.aCP[i+1]-plc.aCP[i]
+		end := int64(fc.fc) + 2*int64(n)
+		if fc.fCompressed {
+			end = int64(fc.fc/2) + int64(n)
+		}
+		if end <= size {
+			return false
+		}
+	}
+	return true
+}
+
+func translateText(b []byte, w *textWriter, fCompressed bool, fib *fib) error {
+	if w.asciiOnly {
+		translateASCII(b, w, fCompressed)
+		return nil
+	}
 	if fCompressed {
 		// Handle compressed (single-byte) text
-		return translateCompressedText(b, buf)
+		return translateCompressedText(b, w)
 	} else {
-		// Handle uncompressed (double-byte) text - typically Unicode
-		return translateUncompressedText(b, buf, fib)
+		// Handle uncompressed (double-byte) text - typically Unicode, but
+		// occasionally code page bytes mislabelled as Unicode
+		var from []int
+		if u, starts, ok := decodeCodepagePiece(b, fib); ok {
+			b, from = u, starts
+		}
+		return translateUncompressedText(b, w, fib, from)
 	}
 }
 
-func translateCompressedText(b []byte, buf *bytes.Buffer) error {
-	fieldLevel := 0
-	var isFieldChar bool
-
-	for cIndex := range b {
+func translateCompressedText(b []byte, w *textWriter) error {
+	w.flushSurrogate()
+	for cIndex := 0; cIndex < len(b) && !w.full(); cIndex++ {
 		// Handle special field characters (section 2.8.25)
-		if b[cIndex] == 0x13 {
-			isFieldChar = true
-			fieldLevel++
+		w.cp = w.pieceCP + cIndex
+		if w.field(uint16(b[cIndex])) {
 			continue
-		} else if b[cIndex] == 0x14 {
-			isFieldChar = false
-			continue
-		} else if b[cIndex] == 0x15 {
-			isFieldChar = false
-			fieldLevel--
+		}
+		if w.inFieldCode() {
+			w.fieldCode(charmap.Windows1252.DecodeByte(b[cIndex]))
 			continue
-		} else if isFieldChar {
+		}
+
+		// Copy runs of printable ASCII straight through; they map to
+		// themselves and can't be the trail byte of a pending character
+		if isPrintableASCII(b[cIndex]) && len(w.pending) == 0 {
+			end := cIndex + 1
+			for end < len(b) && isPrintableASCII(b[end]) {
+				end++
+			}
+			w.writeASCII(b[cIndex:end])
+			cIndex = end - 1
 			continue
 		}
 
+		// Hand high bytes to the custom decoder, along with the trail byte
+		// (always 0x40 or above) of a pending double-byte character
+		if w.decoder != nil {
+			if b[cIndex] >= 0x80 || (len(w.pending) > 0 && b[cIndex] >= 0x40) {
+				w.writeDecoded(b[cIndex], w.pieceFC+cIndex)
+				continue
+			}
+			w.flushDecoder()
+		}
+
 		if b[cIndex] == 7 { // table column separator
-			buf.WriteByte(' ')
+			w.cellMark(w.pieceFC + cIndex)
+			continue
+		} else if b[cIndex] == 13 {
+			w.paragraphMark(w.pieceFC + cIndex)
 			continue
 		} else if b[cIndex] < 32 && b[cIndex] != 9 && b[cIndex] != 10 && b[cIndex] != 13 {
-			// skip non-printable ASCII characters
+			// skip non-printable ASCII characters, keeping any marker they stand for
+			w.writeControl(uint16(b[cIndex]), w.pieceCP+cIndex)
+			continue
+		}
+
+		if isOptionalBreak(rune(b[cIndex])) {
 			continue
 		}
 
 		// Handle compressed characters with special mappings
 		converted := replaceCompressed(b[cIndex])
-		buf.Write(converted)
+		if string(converted) == string(utf8.RuneError) {
+			w.decodeWarning(w.pieceFC+cIndex, b[cIndex])
+			w.writeUndecodable(string(converted))
+			continue
+		}
+		w.write(converted)
 	}
 	return nil
 }
 
-func translateUncompressedText(b []byte, buf *bytes.Buffer, fib *fib) error {
-	fieldLevel := 0
-	var isFieldChar bool
+// isOptionalBreak reports whether r only marks where a line may break,
+// showing nothing otherwise: the soft hyphen (in CP1252 too) and the zero
+// width space. They are dropped, like Word's own optional hyphen (0x1F),
+// so they don't throw off text lengths. The zero width (non-)joiners are
+// kept, as Persian text and emoji sequences rely on them.
+func isOptionalBreak(r rune) bool {
+	return r == 0x00AD || r == 0x200B
+}
+
+// plainSpace maps the typographic spaces (Unicode category Zs) to a plain
+// space for Options.NormalizeSpaces
+func plainSpace(r rune) rune {
+	if unicode.Is(unicode.Zs, r) {
+		return ' '
+	}
+	return r
+}
+
+// collapseWhitespace replaces each run of spaces and tabs in buf with a
+// single space
+func (w *textWriter) collapseWhitespace() {
+	b := w.buf.Bytes()
+	out := b[:0]
+	inRun := false
+	for _, c := range b {
+		if c == ' ' || c == '\t' {
+			if !inRun {
+				out = append(out, ' ')
+			}
+			inRun = true
+			continue
+		}
+		out = append(out, c)
+		inRun = false
+	}
+	w.buf.Truncate(len(out))
+}
+
+// trimTrailing strips what Options.TrimTrailing removes from the end of buf
+func (w *textWriter) trimTrailing() {
+	w.buf.Truncate(len(bytes.TrimRightFunc(w.buf.Bytes(), isTrailingJunk)))
+}
+
+// isTrailingJunk reports whether r is trimmed by Options.TrimTrailing
+func isTrailingJunk(r rune) bool {
+	return unicode.IsSpace(r) || unicode.IsControl(r)
+}
 
+func isPrintableASCII(c byte) bool {
+	return c >= 0x20 && c <= 0x7E
+}
+
+// translateUncompressedText writes UTF-16LE text. from, for pieces that
+// decodeCodepagePiece converted, holds the offset in the piece's own bytes
+// of the character each unit comes from.
+func translateUncompressedText(b []byte, w *textWriter, fib *fib, from []int) error {
 	// Process bytes in pairs for Unicode characters
-	for i := 0; i < len(b)-1; i += 2 {
+	for i := 0; i < len(b)-1 && !w.full(); i += 2 {
 		// Read as little-endian uint16
 		char := binary.LittleEndian.Uint16(b[i : i+2])
+		off := i // of the character in the piece's bytes
+		if from != nil {
+			off = from[i/2]
+		}
+		w.cp = w.pieceCP + off/2
+
+		if w.highSurrogate != 0 {
+			if pair := utf16.DecodeRune(w.highSurrogate, rune(char)); pair != utf8.RuneError {
+				w.highSurrogate = 0
+				w.cp-- // the pair starts at the high surrogate, maybe in the previous piece
+				w.writeRune(pair)
+				continue
+			}
+			w.flushSurrogate()
+		}
 
 		// Handle special field characters
-		if char == 0x13 {
-			isFieldChar = true
-			fieldLevel++
-			continue
-		} else if char == 0x14 {
-			isFieldChar = false
+		if w.field(char) {
 			continue
-		} else if char == 0x15 {
-			isFieldChar = false
-			fieldLevel--
-			continue
-		} else if isFieldChar {
+		}
+		if w.inFieldCode() {
+			w.fieldCode(rune(char))
 			continue
 		}
 
 		if char == 7 { // table column separator
-			buf.WriteByte(' ')
+			w.cellMark(w.pieceFC + off)
+			continue
+		} else if char == 13 {
+			w.paragraphMark(w.pieceFC + off)
 			continue
 		} else if char < 32 && char != 9 && char != 10 && char != 13 {
-			// skip non-printable characters
+			// skip non-printable characters, keeping any marker they stand for
+			w.writeControl(char, w.pieceCP+off/2)
+			continue
+		}
+
+		if isOptionalBreak(rune(char)) {
 			continue
 		}
 
 		// Convert Unicode code point to UTF-8
 		if char <= 0x7F {
 			// ASCII range
-			buf.WriteByte(byte(char))
+			w.writeByte(byte(char))
 		} else {
 			// Unicode character - convert to UTF-8
-			rune := rune(char)
-			if utf8.ValidRune(rune) {
-				utf8Bytes := make([]byte, 4)
-				n := utf8.EncodeRune(utf8Bytes, rune)
-				buf.Write(utf8Bytes[:n])
+			r := rune(char)
+			if utf16.IsSurrogate(r) && r < 0xDC00 {
+				// high surrogate, combined with the next unit, which may
+				// start the next piece
+				w.highSurrogate = r
+				continue
 			}
+			if !utf8.ValidRune(r) {
+				// lone low surrogate
+				w.writeInvalid()
+				continue
+			}
+			w.writeRune(r)
 		}
 	}
 	return nil
 }
 
-// Enhanced character replacement for compressed text
+// Character replacement for compressed text. Compressed pieces are stored as
+// Windows-1252 (section 2.4.1), so every byte from 0x80 up is looked up in
+// charmap.Windows1252: 0x80-0x9F give the CP1252 punctuation and letters, or
+// U+FFFD for the five bytes CP1252 leaves undefined (0x81, 0x8D, 0x8F, 0x90,
+// 0x9D), and 0xA0-0xFF the Latin-1 letters (0xE9 is "é"). Compressed text in
+// other code pages is decoded by startDecoding's decoder instead.
 func replaceCompressed(char byte) []byte {
-	var v uint16
-	switch char {
-	case 0x82:
-		v = 0x201A // Single Low-9 Quotation Mark
-	case 0x83:
-		v = 0x0192 // Latin Small Letter F With Hook
-	case 0x84:
-		v = 0x201E // Double Low-9 Quotation Mark
-	case 0x85:
-		v = 0x2026 // Horizontal Ellipsis
-	case 0x86:
-		v = 0x2020 // Dagger
-	case 0x87:
-		v = 0x2021 // Double Dagger
-	case 0x88:
-		v = 0x02C6 // Modifier Letter Circumflex Accent
-	case 0x89:
-		v = 0x2030 // Per Mille Sign
-	case 0x8A:
-		v = 0x0160 // Latin Capital Letter S With Caron
-	case 0x8B:
-		v = 0x2039 // Single Left-Pointing Angle Quotation Mark
-	case 0x8C:
-		v = 0x0152 // Latin Capital Ligature OE
-	case 0x91:
-		v = 0x2018 // Left Single Quotation Mark
-	case 0x92:
-		v = 0x2019 // Right Single Quotation Mark
-	case 0x93:
-		v = 0x201C // Left Double Quotation Mark
-	case 0x94:
-		v = 0x201D // Right Double Quotation Mark
-	case 0x95:
-		v = 0x2022 // Bullet
-	case 0x96:
-		v = 0x2013 // En Dash
-	case 0x97:
-		v = 0x2014 // Em Dash
-	case 0x98:
-		v = 0x02DC // Small Tilde
-	case 0x99:
-		v = 0x2122 // Trade Mark Sign
-	case 0x9A:
-		v = 0x0161 // Latin Small Letter S With Caron
-	case 0x9B:
-		v = 0x203A // Single Right-Pointing Angle Quotation Mark
-	case 0x9C:
-		v = 0x0153 // Latin Small Ligature OE
-	case 0x9F:
-		v = 0x0178 // Latin Capital Letter Y With Diaeresis
-	default:
-		// For characters in the range 0x80-0xFF that don't have special mappings,
-		// treat as potential ANSI/CP1252 encoding
-		if char >= 0x80 {
-			return handleANSICharacter(char)
-		}
+	if char < 0x80 {
 		return []byte{char}
 	}
+	return decodeWindows1252(char)
+}
 
-	// Convert Unicode code point to UTF-8
-	rune := rune(v)
+// decodeWindows1252 converts a single CP1252 byte to UTF-8
+func decodeWindows1252(char byte) []byte {
+	r := charmap.Windows1252.DecodeByte(char)
 	utf8Bytes := make([]byte, 4)
-	n := utf8.EncodeRune(utf8Bytes, rune)
+	n := utf8.EncodeRune(utf8Bytes, r)
 	return utf8Bytes[:n]
 }
 
-// Handle ANSI/CP1252 characters that might be Chinese characters in some encodings
-func handleANSICharacter(char byte) []byte {
-	// Try to decode as GB2312/GBK for Chinese characters
-	if char >= 0xA1 {
-		// This is a simplified approach - in practice you might need more context
-		// to determine the correct encoding
-		decoder := simplifiedchinese.GBK.NewDecoder()
-
-		// For single-byte character, we need to check if it's part of a multi-byte sequence
-		// This is a basic implementation - you might need to buffer characters
-		input := []byte{char}
-		output := make([]byte, 10)
-
-		nDst, nSrc, err := decoder.Transform(output, input, false)
-		if err == nil && nSrc > 0 && nDst > 0 {
-			return output[:nDst]
-		}
-	}
-
-	// Fallback to original character
-	return []byte{char}
-}
-
 // Helper function to detect potential Chinese text encoding
 func detectChineseEncoding(data []byte, fib *fib) bool {
 	// Check FIB for language information
@@ -311,11 +1109,24 @@ func detectChineseEncoding(data []byte, fib *fib) bool {
 }
 
 func getWordDocAndTables(r *mscfb.Reader) (*mscfb.File, *mscfb.File, *mscfb.File) {
+	return getWordDocAndTablesAt(r, nil, nil)
+}
+
+// getWordDocAndTablesAt finds the streams directly inside the storage at
+// path, so those of embedded documents are never picked up by mistake.
+// Stream names are mapped through normalize first, unless it is nil.
+func getWordDocAndTablesAt(r *mscfb.Reader, path []string, normalize func(string) string) (*mscfb.File, *mscfb.File, *mscfb.File) {
 	var wordDoc, table0, table1 *mscfb.File
-	for i := 0; i < len(r.File); i++ {
-		stream := r.File[i]
+	for _, stream := range r.File {
+		if !samePath(stream.Path, path) {
+			continue
+		}
 
-		switch stream.Name {
+		name := stream.Name
+		if normalize != nil {
+			name = normalize(name)
+		}
+		switch name {
 		case "WordDocument":
 			wordDoc = stream
 		case "0Table":
@@ -323,10 +1134,36 @@ func getWordDocAndTables(r *mscfb.Reader) (*mscfb.File, *mscfb.File, *mscfb.File
 		case "1Table":
 			table1 = stream
 		}
+		if wordDoc != nil && table0 != nil && table1 != nil {
+			break
+		}
 	}
 	return wordDoc, table0, table1
 }
 
+// getStreamAt returns the stream called name in the storage at path (nil
+// for the root), or nil if there is none
+func getStreamAt(r *mscfb.Reader, path []string, name string) *mscfb.File {
+	for _, stream := range r.File {
+		if stream.Name == name && samePath(stream.Path, path) && !stream.FileInfo().IsDir() {
+			return stream
+		}
+	}
+	return nil
+}
+
+func samePath(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
 func getActiveTable(table0 *mscfb.File, table1 *mscfb.File, f *fib) *mscfb.File {
 	if f.base.fWhichTblStm == 0 {
 		return table0
@@ -1,10 +1,21 @@
 package doc
 
 import (
+	"archive/zip"
 	"bytes"
+	"errors"
+	"io"
+	"math/rand"
 	"os"
+	"reflect"
 	"strings"
 	"testing"
+	"time"
+	"unicode/utf8"
+
+	"github.com/richardlehane/mscfb"
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/encoding/simplifiedchinese"
 )
 
 func TestParseSimpleDoc(t *testing.T) {
@@ -108,3 +119,906 @@ Some info from inside a text box
 
 
 `
+
+func TestReplaceCompressedHighBytes(t *testing.T) {
+	// CP1252 0x80-0x9F, undefined positions decode to U+FFFD
+	expected := []rune("€�‚ƒ„…†‡ˆ‰Š‹Œ�Ž��‘’“”•–—˜™š›œ�žŸ")
+	// 0xA0-0xFF match Latin-1
+	for c := 0xA0; c <= 0xFF; c++ {
+		expected = append(expected, rune(c))
+	}
+
+	if s := string(replaceCompressed(0xE9)); s != "é" {
+		t.Errorf("expected 0xE9 to decode to %q, got %q", "é", s)
+	}
+	b := testDoc{pieces: []testPiece{{raw: []byte("Caf\xe9 cr\xe8me\r"), compressed: true}}}.build()
+	buf, err := ParseDoc(bytes.NewReader(b))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := buf.(*bytes.Buffer).String(); s != "Café crème\r" {
+		t.Errorf("expected Latin-1 letters decoded, got %q", s)
+	}
+
+	for c := 0x80; c <= 0xFF; c++ {
+		actual := replaceCompressed(byte(c))
+		if !utf8.Valid(actual) {
+			t.Errorf("expected valid UTF-8 for 0x%X, got %q", c, actual)
+		}
+		if s := string(expected[c-0x80]); string(actual) != s {
+			t.Errorf("mismatch for 0x%X. Expected: %q, Actual: %q", c, s, actual)
+		}
+	}
+}
+
+type blockingReader struct {
+	release chan struct{}
+}
+
+func (b blockingReader) Read(p []byte) (int, error) {
+	<-b.release
+	return 0, io.EOF
+}
+
+func TestParseStreamReadTimeout(t *testing.T) {
+	r := blockingReader{release: make(chan struct{})}
+	defer close(r.release)
+
+	_, err := ParseDocWithOptions(r, Options{ReadTimeout: 10 * time.Millisecond})
+	if !errors.Is(err, ErrReadTimeout) {
+		t.Fatalf("expected ErrReadTimeout, got %v", err)
+	}
+}
+
+func TestParseStream(t *testing.T) {
+	b, err := os.ReadFile(`testData/simpleDoc.doc`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// io.MultiReader hides the io.ReaderAt of the underlying reader
+	buf, err := ParseDocWithOptions(io.MultiReader(bytes.NewReader(b)), Options{ReadTimeout: time.Second})
+	if err != nil {
+		t.Fatal("expected successful parse", err)
+	}
+	if s := buf.(*bytes.Buffer).String(); s != "12345\r" {
+		t.Errorf("expected correct value |%s|", s)
+	}
+}
+
+func TestParseMaxChars(t *testing.T) {
+	b := testDoc{pieces: []testPiece{
+		{text: "Hé\x13 PAGE \x14llo\x15 ", compressed: true},
+		{text: "中文字符\r"},
+		{text: "never read\r", compressed: true},
+	}}.build()
+
+	buf, err := ParseDocWithOptions(bytes.NewReader(b), Options{MaxChars: 8})
+	if err != nil {
+		t.Fatal("expected successful parse", err)
+	}
+	s := buf.(*bytes.Buffer).String()
+	if !utf8.ValidString(s) || utf8.RuneCountInString(s) != 8 {
+		t.Errorf("expected 8 whole runes, got |%s|", s)
+	}
+	if s != "Héllo 中文" {
+		t.Errorf("expected correct value |%s|", s)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	f, err := os.Open(`testData/docFile.doc`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if err := Validate(f); err != nil {
+		t.Errorf("expected valid document, got %v", err)
+	}
+
+	var docx bytes.Buffer
+	zw := zip.NewWriter(&docx)
+	w, _ := zw.Create("word/document.xml")
+	w.Write([]byte("<w:document/>"))
+	zw.Close()
+	if err := Validate(&docx); err != ErrNotOLE2 {
+		t.Errorf("expected ErrNotOLE2 for docx, got %v", err)
+	}
+
+	blob := make([]byte, 4096)
+	rand.New(rand.NewSource(1)).Read(blob)
+	if err := Validate(bytes.NewReader(blob)); err != ErrNotOLE2 {
+		t.Errorf("expected ErrNotOLE2 for random data, got %v", err)
+	}
+
+	noWordDoc := testDoc{noWordDoc: true, pieces: []testPiece{{text: "x", compressed: true}}}.build()
+	if err := Validate(bytes.NewReader(noWordDoc)); err != errDocEmpty {
+		t.Errorf("expected errDocEmpty, got %v", err)
+	}
+
+	noTable := testDoc{noTable: true, pieces: []testPiece{{text: "x", compressed: true}}}.build()
+	if err := Validate(bytes.NewReader(noTable)); err != errTable {
+		t.Errorf("expected errTable, got %v", err)
+	}
+}
+
+func TestParseTabsNearFields(t *testing.T) {
+	b := testDoc{pieces: []testPiece{
+		{text: "\tItem\r\t\tSub item\r\t\t\tSub sub item\r", compressed: true},
+		{text: "\x13 PAGE \x141\x15\t\tafter field\r", compressed: true},
+		{text: "before field\t\t\x13 DATE \\@ \"d\tM\" ", compressed: true}, // field spans pieces
+		{text: "\x14today\x15\t\r"},
+	}}.build()
+
+	buf, err := ParseDoc(bytes.NewReader(b))
+	if err != nil {
+		t.Fatal("expected successful parse", err)
+	}
+	expected := "\tItem\r\t\tSub item\r\t\t\tSub sub item\r1\t\tafter field\rbefore field\t\ttoday\t\r"
+	s := buf.(*bytes.Buffer).String()
+	if strings.Count(s, "\t") != strings.Count(expected, "\t") {
+		t.Errorf("expected %d tabs, got %d", strings.Count(expected, "\t"), strings.Count(s, "\t"))
+	}
+	if s != expected {
+		t.Errorf("expected correct value |%s|", s)
+	}
+}
+
+func TestParseCustomDecoder(t *testing.T) {
+	cp437 := testDoc{pieces: []testPiece{
+		{raw: []byte("Fa\x87ade \x81ber \xb0\xb1\xb2\r"), compressed: true},
+	}}.build()
+	buf, err := ParseDocWithOptions(bytes.NewReader(cp437), Options{CustomDecoder: charmap.CodePage437.NewDecoder()})
+	if err != nil {
+		t.Fatal("expected successful parse", err)
+	}
+	if s := buf.(*bytes.Buffer).String(); s != "Façade über ░▒▓\r" {
+		t.Errorf("expected correct value |%s|", s)
+	}
+
+	// a GBK character split between two pieces
+	gbk := testDoc{pieces: []testPiece{
+		{raw: []byte("A\xd6"), compressed: true},
+		{raw: []byte("\xd0\xce\xc4\r"), compressed: true},
+	}}.build()
+	buf, err = ParseDocWithOptions(bytes.NewReader(gbk), Options{CustomDecoder: simplifiedchinese.GBK.NewDecoder()})
+	if err != nil {
+		t.Fatal("expected successful parse", err)
+	}
+	if s := buf.(*bytes.Buffer).String(); s != "A中文\r" {
+		t.Errorf("expected correct value |%s|", s)
+	}
+}
+
+func TestParseReplaceInvalid(t *testing.T) {
+	// "a", lone high surrogate, "b", U+1F600 as a surrogate pair, lone low surrogate, CR
+	raw := []byte{'a', 0, 0x00, 0xD8, 'b', 0, 0x3D, 0xD8, 0x00, 0xDE, 0x00, 0xDC, '\r', 0}
+	b := testDoc{pieces: []testPiece{{raw: raw}}}.build()
+
+	buf, err := ParseDoc(bytes.NewReader(b))
+	if err != nil {
+		t.Fatal("expected successful parse", err)
+	}
+	if s := buf.(*bytes.Buffer).String(); s != "ab😀\r" {
+		t.Errorf("expected invalid units to be dropped |%s|", s)
+	}
+
+	buf, err = ParseDocWithOptions(bytes.NewReader(b), Options{ReplaceInvalid: true})
+	if err != nil {
+		t.Fatal("expected successful parse", err)
+	}
+	if s := buf.(*bytes.Buffer).String(); s != "a�b😀�\r" {
+		t.Errorf("expected invalid units to be replaced |%s|", s)
+	}
+}
+
+func TestTranslateCompressedASCIIRuns(t *testing.T) {
+	b := []byte("plain text\there \x93quoted\x94 caf\xe9\x13 HYPERLINK \"x\" \x14link\x15 end\r")
+	var expected bytes.Buffer
+	inField := false
+	for _, c := range b {
+		switch {
+		case c == 0x13:
+			inField = true
+		case c == 0x14, c == 0x15:
+			inField = false
+		case !inField:
+			expected.Write(replaceCompressed(c))
+		}
+	}
+
+	w := newTextWriter(Options{})
+	if err := translateCompressedText(b, w); err != nil {
+		t.Fatal(err)
+	}
+	if s := w.buf.String(); s != expected.String() {
+		t.Errorf("expected |%s|, got |%s|", expected.String(), s)
+	}
+
+	w = newTextWriter(Options{MaxChars: 4})
+	translateCompressedText(b, w)
+	if s := w.buf.String(); s != "plai" {
+		t.Errorf("expected MaxChars to cut the ASCII run |%s|", s)
+	}
+}
+
+func BenchmarkTranslateCompressed(b *testing.B) {
+	ascii := bytes.Repeat([]byte("The quick brown fox jumps over the lazy dog.\r"), 2000)
+	mixed := bytes.Repeat([]byte("Caf\xe9 \x93cr\xe8me br\xfbl\xe9e\x94\t\xa71\r"), 2000)
+	for _, bm := range []struct {
+		name string
+		text []byte
+	}{{"ASCII", ascii}, {"Mixed", mixed}} {
+		b.Run(bm.name, func(b *testing.B) {
+			b.SetBytes(int64(len(bm.text)))
+			for i := 0; i < b.N; i++ {
+				translateCompressedText(bm.text, newTextWriter(Options{}))
+			}
+		})
+	}
+}
+
+func TestRawWordDocument(t *testing.T) {
+	f, err := os.Open(`testData/docFile.doc`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	b, err := RawWordDocument(f)
+	if err != nil {
+		t.Fatal("expected successful read", err)
+	}
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		t.Fatal(err)
+	}
+	d, err := mscfb.New(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wordDoc, _, _ := getWordDocAndTables(d)
+	if int64(len(b)) != wordDoc.Size {
+		t.Errorf("expected %d bytes, got %d", wordDoc.Size, len(b))
+	}
+	if len(b) < 2 || b[0] != 0xEC || b[1] != 0xA5 {
+		t.Errorf("expected the stream to start with the FIB magic")
+	}
+
+	noWordDoc := testDoc{noWordDoc: true, pieces: []testPiece{{text: "x", compressed: true}}}.build()
+	if _, err := RawWordDocument(bytes.NewReader(noWordDoc)); !errors.Is(err, errDocEmpty) {
+		t.Errorf("expected errDocEmpty, got %v", err)
+	}
+}
+
+func TestParseNestedFields(t *testing.T) {
+	b := testDoc{pieces: []testPiece{
+		// IF field whose instructions hold a nested MERGEFIELD
+		{text: "Dear \x13 IF \x13 MERGEFIELD Title \x14Dr\x15 = \"Dr\" \"Doctor\" \"\" \x14Doctor\x15 Smith,\r", compressed: true},
+		// hyperlink whose result holds a nested PAGE field
+		{text: "See \x13 HYPERLINK \"#p\" \x14page \x13 PAGE \x147\x15\x15.\r", compressed: true},
+		// DATE field with an empty result, and a field without a result
+		{text: "Printed \x13 DATE \x14\x15on\x13 XE \"index\" \x15 demand\r"},
+	}}.build()
+
+	buf, err := ParseDoc(bytes.NewReader(b))
+	if err != nil {
+		t.Fatal("expected successful parse", err)
+	}
+	expected := "Dear Doctor Smith,\rSee page 7.\rPrinted on demand\r"
+	if s := buf.(*bytes.Buffer).String(); s != expected {
+		t.Errorf("expected correct value |%s|", s)
+	}
+}
+
+func TestParseSpecialCharacters(t *testing.T) {
+	// stray field separators and ends, a second separator in a result,
+	// a picture, a drawn object and both kinds of hyphen
+	text := "\x14Call\x15 \x13 PAGE \x141\x14\x15 e\x1Email\x01\x08 hy\x1Fphen\r"
+	want := "Call 1 e\u2011mail hyphen\r"
+	for _, compressed := range []bool{true, false} {
+		b := testDoc{pieces: []testPiece{{text: text, compressed: compressed}}}.build()
+		buf, err := ParseDoc(bytes.NewReader(b))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if s := buf.(*bytes.Buffer).String(); s != want {
+			t.Errorf("compressed %v: expected %q, got %q", compressed, want, s)
+		}
+	}
+}
+
+func TestParseEmptyCompoundFile(t *testing.T) {
+	empty := buildCFB(nil) // a valid header and a root storage, but no streams
+	if _, err := ParseDoc(bytes.NewReader(empty)); !errors.Is(err, errDocEmpty) {
+		t.Errorf("expected errDocEmpty, got %v", err)
+	}
+	if err := Validate(bytes.NewReader(empty)); err != errDocEmpty {
+		t.Errorf("expected errDocEmpty, got %v", err)
+	}
+}
+
+func TestParseEmptyDocument(t *testing.T) {
+	b := testDoc{}.build() // a WordDocument stream with no text
+	r, err := ParseDoc(bytes.NewReader(b))
+	if err != nil {
+		t.Fatal("expected successful parse", err)
+	}
+	if r == nil {
+		t.Fatal("expected a non-nil reader")
+	}
+	if s, err := io.ReadAll(r); err != nil || len(s) != 0 {
+		t.Errorf("expected no text, got %q, %v", s, err)
+	}
+	if err := Validate(bytes.NewReader(b)); err != nil {
+		t.Errorf("expected a valid document, got %v", err)
+	}
+}
+
+func TestParseLineEnding(t *testing.T) {
+	b := testDoc{pieces: []testPiece{
+		{text: "First paragraph\rSecond\x0bline\r", compressed: true},
+		{text: "Третий\r"},
+	}}.build()
+
+	for _, test := range []struct {
+		lineEnding LineEnding
+		expected   string
+	}{
+		{"", "First paragraph\rSecondline\rТретий\r"},
+		{CRLF, "First paragraph\r\nSecond\r\nline\r\nТретий\r\n"},
+		{LF, "First paragraph\nSecond\nline\nТретий\n"},
+		{CR, "First paragraph\rSecond\rline\rТретий\r"},
+	} {
+		buf, err := ParseDocWithOptions(bytes.NewReader(b), Options{LineEnding: test.lineEnding})
+		if err != nil {
+			t.Fatal("expected successful parse", err)
+		}
+		if s := buf.(*bytes.Buffer).String(); s != test.expected {
+			t.Errorf("expected %q for line ending %q, got %q", test.expected, test.lineEnding, s)
+		}
+	}
+}
+
+func TestParseSurrogatePairAcrossPieces(t *testing.T) {
+	b := testDoc{pieces: []testPiece{
+		{raw: []byte{'a', 0, 0x3D, 0xD8}},             // "a", high surrogate of U+1F600
+		{raw: []byte{0x00, 0xDE, 'b', 0, 0x3C, 0xD8}}, // its low surrogate, "b", a high surrogate
+		{text: "c\r", compressed: true},               // never completed
+	}}.build()
+
+	buf, err := ParseDoc(bytes.NewReader(b))
+	if err != nil {
+		t.Fatal("expected successful parse", err)
+	}
+	if s := buf.(*bytes.Buffer).String(); s != "a😀bc\r" {
+		t.Errorf("expected pair to combine across pieces |%s|", s)
+	}
+
+	buf, err = ParseDocWithOptions(bytes.NewReader(b), Options{ReplaceInvalid: true})
+	if err != nil {
+		t.Fatal("expected successful parse", err)
+	}
+	if s := buf.(*bytes.Buffer).String(); s != "a😀b�c\r" {
+		t.Errorf("expected unpaired high surrogate to be replaced |%s|", s)
+	}
+}
+
+func TestParsePieceDelimiter(t *testing.T) {
+	b := testDoc{pieces: []testPiece{
+		{text: "one ", compressed: true},
+		// pieces that write nothing get no delimiter
+		{text: "secret ", compressed: true, grpprl: []byte{0x3C, 0x08, 0x01}},
+		{text: "two "},
+		{text: "three\r", compressed: true},
+	}}.build()
+
+	buf, err := ParseDocWithOptions(bytes.NewReader(b), Options{PieceDelimiter: "|"})
+	if err != nil {
+		t.Fatal("expected successful parse", err)
+	}
+	s := buf.(*bytes.Buffer).String()
+	if strings.Count(s, "|") != 2 || s != "one |two |three\r" {
+		t.Errorf("expected a delimiter between each piece |%s|", s)
+	}
+
+	// the delimiter doesn't count toward MaxChars, and isn't written
+	// once the limit is reached
+	for maxChars, expected := range map[int]string{4: "one ", 5: "one |t"} {
+		buf, err = ParseDocWithOptions(bytes.NewReader(b), Options{PieceDelimiter: "|", MaxChars: maxChars})
+		if err != nil {
+			t.Fatal("expected successful parse", err)
+		}
+		if s := buf.(*bytes.Buffer).String(); s != expected {
+			t.Errorf("MaxChars %d: expected %q, got %q", maxChars, expected, s)
+		}
+	}
+}
+
+func TestParseReferenceMarks(t *testing.T) {
+	b := testDoc{pieces: []testPiece{
+		{text: "Water boils at 100 °C.\x02 It freezes", compressed: true},
+		{text: " at 0 °C.\x02\x05\r"},
+	}}.build()
+
+	buf, err := ParseDoc(bytes.NewReader(b))
+	if err != nil {
+		t.Fatal("expected successful parse", err)
+	}
+	if s := buf.(*bytes.Buffer).String(); s != "Water boils at 100 °C. It freezes at 0 °C.\r" {
+		t.Errorf("expected reference marks to be dropped |%s|", s)
+	}
+
+	buf, err = ParseDocWithOptions(bytes.NewReader(b), Options{FootnoteMarker: "[%d]"})
+	if err != nil {
+		t.Fatal("expected successful parse", err)
+	}
+	if s := buf.(*bytes.Buffer).String(); s != "Water boils at 100 °C.[1] It freezes at 0 °C.[2]\r" {
+		t.Errorf("expected numbered footnote markers |%s|", s)
+	}
+}
+
+func TestGetWordDocAndTables(t *testing.T) {
+	embedded := testDoc{pieces: []testPiece{{text: "embedded\r", compressed: true}}}.entries()
+	b := testDoc{
+		pieces: []testPiece{{text: "main\r", compressed: true}},
+		streams: []cfbEntry{
+			{name: "0Table", data: make([]byte, 16)},
+			{name: "Data", data: make([]byte, 64)},
+			{name: "ObjectPool", storage: true, children: []cfbEntry{
+				{name: "_1234", storage: true, children: embedded},
+			}},
+			{name: "CompObj", data: make([]byte, 8)},
+		},
+	}.build()
+
+	d, err := mscfb.New(bytes.NewReader(b))
+	if err != nil {
+		t.Fatal(err)
+	}
+	wordDoc, table0, table1 := getWordDocAndTables(d)
+	for name, f := range map[string]*mscfb.File{"WordDocument": wordDoc, "0Table": table0, "1Table": table1} {
+		if f == nil || f.Name != name || len(f.Path) != 0 {
+			t.Errorf("expected root-level %s, got %+v", name, f)
+		}
+	}
+	if table0.Size != 16 {
+		t.Errorf("expected the extra 0Table stream, got %d bytes", table0.Size)
+	}
+
+	buf, err := ParseDoc(bytes.NewReader(b))
+	if err != nil {
+		t.Fatal("expected successful parse", err)
+	}
+	if s := buf.(*bytes.Buffer).String(); s != "main\r" {
+		t.Errorf("expected correct value |%s|", s)
+	}
+}
+
+func TestParseTrimTrailing(t *testing.T) {
+	for _, tc := range []struct {
+		pieces []testPiece
+		want   string
+	}{
+		{[]testPiece{{text: "Last line.\r", compressed: true}, {text: " \t\r \r", compressed: true}}, "Last line."},
+		{[]testPiece{{text: "Unicode padding\r"}, {raw: []byte{0x0D, 0, 0x20, 0, 0x85, 0, 0x0D, 0}}}, "Unicode padding"},
+		{[]testPiece{{text: "No padding...", compressed: true}}, "No padding..."},
+		{[]testPiece{{text: " \r\r", compressed: true}}, ""},
+	} {
+		b := testDoc{pieces: tc.pieces}.build()
+		r, err := ParseDocWithOptions(bytes.NewReader(b), Options{TrimTrailing: true})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got, _ := io.ReadAll(r); string(got) != tc.want {
+			t.Errorf("got %q, want %q", got, tc.want)
+		}
+	}
+}
+
+func TestParseCollapseWhitespace(t *testing.T) {
+	b := testDoc{pieces: []testPiece{
+		{text: "Name:\t\t\tJane  Doe   \r", compressed: true},
+		{text: "Total  \t  12 €\x0b\tpaid\r \r"},
+	}}.build()
+	for _, tc := range []struct {
+		opts Options
+		want string
+	}{
+		{Options{CollapseWhitespace: true}, "Name: Jane Doe \rTotal 12 € paid\r \r"},
+		{Options{CollapseWhitespace: true, LineEnding: LF}, "Name: Jane Doe \nTotal 12 €\n paid\n \n"},
+		{Options{CollapseWhitespace: true, TrimTrailing: true}, "Name: Jane Doe \rTotal 12 € paid"},
+	} {
+		r, err := ParseDocWithOptions(bytes.NewReader(b), tc.opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got, _ := io.ReadAll(r); string(got) != tc.want {
+			t.Errorf("got %q, want %q", got, tc.want)
+		}
+	}
+}
+
+func TestParseOnProgress(t *testing.T) {
+	b := testDoc{pieces: []testPiece{
+		{text: "First piece. ", compressed: true},
+		{text: "Second piece. "},
+		{text: "Third piece.\r", compressed: true},
+	}}.build()
+
+	var reads []int64
+	opts := Options{OnProgress: func(readBytes, totalBytes int64) {
+		if totalBytes != int64(len(b)) {
+			t.Errorf("expected total %d, got %d", len(b), totalBytes)
+		}
+		reads = append(reads, readBytes)
+	}}
+	if _, err := ParseDocWithOptions(bytes.NewReader(b), opts); err != nil {
+		t.Fatal(err)
+	}
+	if want := []int64{13, 41, 54}; !reflect.DeepEqual(reads, want) {
+		t.Errorf("expected progress %v, got %v", want, reads)
+	}
+
+	// an io.ReaderAt whose size isn't known
+	reads = nil
+	var totals []int64
+	opts.OnProgress = func(readBytes, totalBytes int64) {
+		reads = append(reads, readBytes)
+		totals = append(totals, totalBytes)
+	}
+	if _, err := ParseDocWithOptions(sizelessReader{bytes.NewReader(b)}, opts); err != nil {
+		t.Fatal(err)
+	}
+	if len(reads) != 3 || totals[0] != -1 {
+		t.Errorf("expected 3 calls with an unknown total, got %v and %v", reads, totals)
+	}
+}
+
+// sizelessReader hides the Size method of a bytes.Reader
+type sizelessReader struct {
+	r *bytes.Reader
+}
+
+func (s sizelessReader) Read(p []byte) (int, error) { return s.r.Read(p) }
+
+func (s sizelessReader) ReadAt(p []byte, off int64) (int, error) { return s.r.ReadAt(p, off) }
+
+func TestParseOptionalBreaks(t *testing.T) {
+	b := testDoc{pieces: []testPiece{
+		{text: "Opti\u001fmal, soft\u00adware, zero\u200bwidth, مي\u200cخواهم\r"},
+		{raw: []byte("Com\x1fpressed soft\xadware\r"), compressed: true},
+	}}.build()
+
+	buf, err := ParseDoc(bytes.NewReader(b))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := buf.(*bytes.Buffer).String(); s != "Optimal, software, zerowidth, مي\u200cخواهم\rCompressed software\r" {
+		t.Errorf("expected optional breaks to be dropped, got %q", s)
+	}
+}
+
+func TestParseOnDecodeWarning(t *testing.T) {
+	b := testDoc{pieces: []testPiece{{raw: []byte("ok \x81 caf\xe9 \x9d\r"), compressed: true}}}.build()
+
+	type warning struct {
+		offset int
+		b      byte
+	}
+	var warnings []warning
+	opts := Options{OnDecodeWarning: func(offset int, b byte) {
+		warnings = append(warnings, warning{offset, b})
+	}}
+	buf, err := ParseDocWithOptions(bytes.NewReader(b), opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := buf.(*bytes.Buffer).String(); s != "ok � café �\r" {
+		t.Errorf("unexpected text %q", s)
+	}
+	expected := []warning{{testTextOffset + 3, 0x81}, {testTextOffset + 10, 0x9d}}
+	if !reflect.DeepEqual(warnings, expected) {
+		t.Errorf("expected warnings %v, got %v", expected, warnings)
+	}
+
+	// bytes the custom decoder rejects
+	warnings = nil
+	b = testDoc{pieces: []testPiece{{raw: []byte("\xb5\xc4\x81\x7f\r"), compressed: true}}}.build()
+	opts.CustomDecoder = simplifiedchinese.GBK.NewDecoder()
+	buf, err = ParseDocWithOptions(bytes.NewReader(b), opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := buf.(*bytes.Buffer).String(); s != "的�\x7f\r" {
+		t.Errorf("unexpected text %q", s)
+	}
+	if expected := []warning{{testTextOffset + 2, 0x81}}; !reflect.DeepEqual(warnings, expected) {
+		t.Errorf("expected warnings %v, got %v", expected, warnings)
+	}
+}
+
+func TestParseDropPlaceholder(t *testing.T) {
+	for _, test := range []struct {
+		pieces   []testPiece
+		decoder  bool
+		expected string
+	}{
+		{[]testPiece{{raw: []byte("ok \x81 caf\xe9 \x9d\r"), compressed: true}}, false, "ok ? café ?\r"},
+		{[]testPiece{{raw: []byte{'a', 0, 0x00, 0xDC, 'b', 0, 0x00, 0xD8, '\r', 0}}}, false, "a?b?\r"},
+		{[]testPiece{{raw: []byte("\xb5\xc4\x81\r"), compressed: true}}, true, "的?\r"},
+	} {
+		opts := Options{DropPlaceholder: "?", ReplaceInvalid: true}
+		if test.decoder {
+			opts.CustomDecoder = simplifiedchinese.GBK.NewDecoder()
+		}
+		buf, err := ParseDocWithOptions(bytes.NewReader(testDoc{pieces: test.pieces}.build()), opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if s := buf.(*bytes.Buffer).String(); s != test.expected {
+			t.Errorf("expected %q, got %q", test.expected, s)
+		}
+	}
+}
+
+func TestParseTypographicSpaces(t *testing.T) {
+	b := testDoc{pieces: []testPiece{
+		{text: "em\u2003en\u2002thin\u2009"},
+		{text: "no\u00a0break\r", compressed: true},
+	}}.build()
+	for _, tc := range []struct {
+		normalize bool
+		want      string
+	}{
+		{false, "em\u2003en\u2002thin\u2009no\u00a0break\r"},
+		{true, "em en thin no break\r"},
+	} {
+		buf, err := ParseDocWithOptions(bytes.NewReader(b), Options{NormalizeSpaces: tc.normalize})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if s := buf.(*bytes.Buffer).String(); s != tc.want {
+			t.Errorf("NormalizeSpaces %v: expected %q, got %q", tc.normalize, tc.want, s)
+		}
+	}
+}
+
+// writeCounter counts the Write calls made on it
+type writeCounter struct {
+	writes, n int
+}
+
+func (c *writeCounter) Write(p []byte) (int, error) {
+	c.writes++
+	c.n += len(p)
+	return len(p), nil
+}
+
+func TestParseWriterTo(t *testing.T) {
+	// longer than io.Copy's 32 KiB buffer, which would take several writes
+	text := strings.Repeat("A paragraph of text.\r", 5000)
+	b := testDoc{pieces: []testPiece{{text: text, compressed: true}}}.build()
+	for name, parse := range map[string]func() (io.Reader, error){
+		"ParseDoc":            func() (io.Reader, error) { return ParseDoc(bytes.NewReader(b)) },
+		"ParseDocWithOptions": func() (io.Reader, error) { return ParseDocWithOptions(bytes.NewReader(b), Options{TrimTrailing: true}) },
+		"ParseDocReadCloser":  func() (io.Reader, error) { return ParseDocReadCloser(bytes.NewReader(b)) },
+	} {
+		r, err := parse()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, ok := r.(io.WriterTo); !ok {
+			t.Fatalf("%s: reader %T doesn't implement io.WriterTo", name, r)
+		}
+		var c writeCounter
+		if _, err := io.Copy(&c, r); err != nil {
+			t.Fatal(err)
+		}
+		if c.writes != 1 || c.n < len(text)-1 {
+			t.Errorf("%s: expected a single write of the text, got %d writes of %d bytes", name, c.writes, c.n)
+		}
+	}
+
+	rc, err := ParseDocReadCloser(bytes.NewReader(b))
+	if err != nil {
+		t.Fatal(err)
+	}
+	rc.Close()
+	if _, err := io.Copy(io.Discard, rc); !errors.Is(err, ErrClosed) {
+		t.Errorf("expected ErrClosed copying after Close, got %v", err)
+	}
+}
+
+func TestParseTruncatedWordDocument(t *testing.T) {
+	pieces := []testPiece{{text: "First piece. ", compressed: true}, {text: "Second piece\r"}}
+	for _, test := range []struct {
+		size     int
+		expected string
+		cp       int
+	}{
+		{testTextOffset + 13 + 9, "First piece. Seco", 17}, // half a UTF-16 unit is dropped
+		{testTextOffset + 13, "First piece. ", 13},
+		{testTextOffset + 5, "First", 5},
+	} {
+		entries := testDoc{pieces: pieces}.entries()
+		for i, e := range entries {
+			if e.name == "WordDocument" {
+				entries[i].data = e.data[:test.size]
+			}
+		}
+		truncatedAt := -1
+		opts := Options{OnTruncated: func(cp int) { truncatedAt = cp }}
+		buf, err := ParseDocWithOptions(bytes.NewReader(buildCFB(entries)), opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if s := buf.(*bytes.Buffer).String(); s != test.expected {
+			t.Errorf("expected %q, got %q", test.expected, s)
+		}
+		if truncatedAt != test.cp {
+			t.Errorf("expected truncation reported at CP %d, got %d", test.cp, truncatedAt)
+		}
+	}
+}
+
+// countingReaderAt counts the bytes read through it
+type countingReaderAt struct {
+	ra   io.ReaderAt
+	read int64
+}
+
+func (c *countingReaderAt) ReadAt(p []byte, off int64) (int, error) {
+	n, err := c.ra.ReadAt(p, off)
+	c.read += int64(n)
+	return n, err
+}
+
+func TestParseReaderAt(t *testing.T) {
+	b := testDoc{
+		pieces:  []testPiece{{text: "Read in place.\r", compressed: true}},
+		streams: []cfbEntry{{name: "Data", data: make([]byte, 1<<20)}},
+	}.build()
+
+	c := &countingReaderAt{ra: bytes.NewReader(b)}
+	r, err := ParseReaderAt(c, int64(len(b)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := io.ReadAll(r); string(got) != "Read in place.\r" {
+		t.Errorf("got %q", got)
+	}
+	if c.read >= int64(len(b))/4 {
+		t.Errorf("expected the file not to be buffered, read %d of %d bytes", c.read, len(b))
+	}
+}
+
+func TestParseExcludesComments(t *testing.T) {
+	b := testDoc{
+		pieces:    []testPiece{{text: "Body\x05 text.\r", compressed: true}, {text: "Second\x05 paragraph.\r"}},
+		comments:  []string{"First comment\r", "Second comment\r"},
+		textboxes: []string{"Boxed\r"},
+	}.build()
+
+	for name, parse := range map[string]func() (string, error){
+		"ParseDoc": func() (string, error) {
+			r, err := ParseDoc(bytes.NewReader(b))
+			if err != nil {
+				return "", err
+			}
+			s, err := io.ReadAll(r)
+			return string(s), err
+		},
+		"ParseWithOffsets": func() (string, error) {
+			s, _, err := ParseWithOffsets(bytes.NewReader(b))
+			return s, err
+		},
+	} {
+		s, err := parse()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if s != "Body text.\rSecond paragraph.\r" {
+			t.Errorf("%s: expected only the body text, got %q", name, s)
+		}
+	}
+
+	boxes, err := ExtractTextboxes(bytes.NewReader(b))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(boxes) != 1 || boxes[0] != "Boxed" {
+		t.Errorf("expected the text box after the comments, got %q", boxes)
+	}
+}
+
+func TestParseStreamNameNormalizer(t *testing.T) {
+	entries := testDoc{pieces: []testPiece{{text: "Renamed streams\r", compressed: true}}}.entries()
+	for i, e := range entries {
+		entries[i].name = " " + strings.ToLower(e.name)
+	}
+	b := buildCFB(entries)
+
+	if _, err := ParseDoc(bytes.NewReader(b)); !errors.Is(err, errDocEmpty) {
+		t.Errorf("expected errDocEmpty without a normalizer, got %v", err)
+	}
+
+	canonical := map[string]string{"worddocument": "WordDocument", "0table": "0Table", "1table": "1Table"}
+	normalize := func(name string) string {
+		if c, ok := canonical[strings.ToLower(strings.TrimSpace(name))]; ok {
+			return c
+		}
+		return name
+	}
+	buf, err := ParseDocWithOptions(bytes.NewReader(b), Options{StreamNameNormalizer: normalize})
+	if err != nil {
+		t.Fatal("expected successful parse", err)
+	}
+	if s := buf.(*bytes.Buffer).String(); s != "Renamed streams\r" {
+		t.Errorf("expected %q, got %q", "Renamed streams\r", s)
+	}
+}
+
+func TestParseDocReadCloser(t *testing.T) {
+	b := testDoc{pieces: []testPiece{{text: "Closable text\r", compressed: true}}}.build()
+	rc, err := ParseDocReadCloser(bytes.NewReader(b))
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := make([]byte, 8)
+	if n, err := rc.Read(p); err != nil || string(p[:n]) != "Closable" {
+		t.Errorf("expected %q, got %q, %v", "Closable", p[:n], err)
+	}
+	for i := 0; i < 2; i++ {
+		if err := rc.Close(); err != nil {
+			t.Errorf("Close %d: %v", i+1, err)
+		}
+	}
+	if _, err := rc.Read(p); !errors.Is(err, ErrClosed) {
+		t.Errorf("expected ErrClosed after Close, got %v", err)
+	}
+
+	if rc, err := ParseDocReadCloser(bytes.NewReader([]byte("not a document"))); err == nil || rc != nil {
+		t.Errorf("expected an error and no reader, got %v, %v", rc, err)
+	}
+}
+
+func TestParseFinalParagraphMark(t *testing.T) {
+	for name, test := range map[string]struct {
+		d                 testDoc
+		expected, dropped string
+	}{
+		"content": {
+			testDoc{pieces: []testPiece{{text: "First\rLast line\r", compressed: true}}},
+			"First\nLast line\n", "First\nLast line",
+		},
+		"empty last paragraph": {
+			testDoc{pieces: []testPiece{{text: "Text\r\r", compressed: true}}},
+			"Text\n\n", "Text\n",
+		},
+		"mark in its own piece": {
+			testDoc{pieces: []testPiece{{text: "Body", compressed: true}, {text: "\r"}}},
+			"Body\n", "Body",
+		},
+		"subdocuments": {
+			testDoc{pieces: []testPiece{{text: "Body\x05\r", compressed: true}}, comments: []string{"Note\r"}, textboxes: []string{"Box\r"}},
+			"Body\n", "Body",
+		},
+		"no mark": {
+			testDoc{pieces: []testPiece{{text: "First\rNo mark", compressed: true}}},
+			"First\nNo mark", "First\nNo mark",
+		},
+	} {
+		b := test.d.build()
+		for _, drop := range []bool{false, true} {
+			expected := test.expected
+			if drop {
+				expected = test.dropped
+			}
+			buf, err := ParseDocWithOptions(bytes.NewReader(b), Options{LineEnding: LF, DropFinalMark: drop})
+			if err != nil {
+				t.Fatal(name, err)
+			}
+			if s := buf.(*bytes.Buffer).String(); s != expected {
+				t.Errorf("%s, DropFinalMark %v: expected %q, got %q", name, drop, expected, s)
+			}
+		}
+	}
+}
@@ -0,0 +1,152 @@
+package doc
+
+import (
+	"errors"
+	"io"
+	"strings"
+)
+
+// Document is the structured form of a .doc file: its main text split into
+// paragraphs and runs, plus the document properties
+type Document struct {
+	Metadata   Metadata    `json:"metadata"`
+	Paragraphs []Paragraph `json:"paragraphs"`
+}
+
+// Paragraph is the text between two paragraph marks
+type Paragraph struct {
+	Runs []Run `json:"runs"`
+	// RTL is set for right-to-left paragraphs. Runs are in logical order.
+	RTL bool `json:"rtl,omitempty"`
+	// Style is the name of the paragraph's style, such as "Normal"
+	Style string `json:"style,omitempty"`
+}
+
+// Run is a span of text within a paragraph. Runs break wherever the
+// character properties change and also at piece and field boundaries, so
+// adjacent runs may share the same formatting.
+type Run struct {
+	Text string `json:"text"`
+	// Lang is the language identifier ([MS-LCID]) of the run, falling back
+	// to the document's language when the run doesn't set one
+	Lang uint16 `json:"lang,omitempty"`
+}
+
+// Text returns the text of all runs in p
+func (p Paragraph) Text() string {
+	var sb strings.Builder
+	for _, run := range p.Runs {
+		sb.WriteString(run.Text)
+	}
+	return sb.String()
+}
+
+// ParseDocument parses the .doc file in r into a Document
+func ParseDocument(r io.Reader) (*Document, error) {
+	return ParseDocumentWithOptions(r, Options{})
+}
+
+// ParseDocumentWithOptions is like ParseDocument but reads and translates
+// the document according to opts
+func ParseDocumentWithOptions(r io.Reader, opts Options) (*Document, error) {
+	ra, release, err := readerAt(r, opts)
+	if err != nil {
+		return nil, wrapError(err)
+	}
+	defer release()
+
+	pd, err := openDoc(ra, opts)
+	if err != nil {
+		return nil, wrapError(err)
+	}
+
+	d := &Document{Paragraphs: []Paragraph{}}
+	err = walkRuns(pd, opts, func(e RunEvent) error {
+		switch e.Kind {
+		case ParagraphStart:
+			d.Paragraphs = append(d.Paragraphs, Paragraph{Runs: []Run{}})
+		case RunText:
+			p := &d.Paragraphs[len(d.Paragraphs)-1]
+			p.Runs = append(p.Runs, Run{Text: e.Text, Lang: e.Lang})
+		case ParagraphEnd:
+			p := &d.Paragraphs[len(d.Paragraphs)-1]
+			p.RTL, p.Style = e.RTL, e.Style
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	metadata, err := getMetadata(pd.cfb)
+	if err != nil {
+		return nil, wrapError(err)
+	}
+	d.Metadata = *metadata
+	return d, nil
+}
+
+// HasRTL reports whether the .doc file in r has any right-to-left
+// paragraphs or runs
+func HasRTL(r io.Reader) (bool, error) {
+	found := errors.New("found")
+	err := WalkRuns(r, func(e RunEvent) error {
+		if e.RTL {
+			return found
+		}
+		return nil
+	})
+	if err == found {
+		return true, nil
+	}
+	return false, err
+}
+
+// FirstParagraph returns the text of the first paragraph of the .doc file
+// in r that isn't empty or blank, without its paragraph mark, for previews
+// and snippets. Translation stops at the end of that paragraph. A document
+// without one gives an empty string.
+func FirstParagraph(r io.Reader) (string, error) {
+	var text strings.Builder
+	found := errors.New("found")
+	err := WalkRuns(r, func(e RunEvent) error {
+		switch e.Kind {
+		case ParagraphStart:
+			text.Reset()
+		case RunText:
+			text.WriteString(e.Text)
+		case ParagraphEnd:
+			if strings.TrimSpace(text.String()) != "" {
+				return found
+			}
+		}
+		return nil
+	})
+	if err != nil && err != found {
+		return "", err
+	}
+	if strings.TrimSpace(text.String()) == "" {
+		return "", nil
+	}
+	return text.String(), nil
+}
+
+// DocumentLanguages returns the distinct language identifiers ([MS-LCID])
+// of the runs in the .doc file in r, in order of first use
+func DocumentLanguages(r io.Reader) ([]uint16, error) {
+	d, err := ParseDocument(r)
+	if err != nil {
+		return nil, err
+	}
+	langs := []uint16{}
+	seen := map[uint16]bool{}
+	for _, p := range d.Paragraphs {
+		for _, run := range p.Runs {
+			if !seen[run.Lang] {
+				seen[run.Lang] = true
+				langs = append(langs, run.Lang)
+			}
+		}
+	}
+	return langs, nil
+}
@@ -0,0 +1,159 @@
+package doc
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"os"
+	"reflect"
+	"testing"
+)
+
+var richDoc = testDoc{
+	pieces: []testPiece{
+		{text: "Quarterly report\r", compressed: true},
+		{text: "Revenue grew in ", compressed: true},
+		{text: "中国 & \"EU\"\r"},
+		{text: "\r", compressed: true},
+		{text: "Closing remarks", compressed: true},
+	},
+	streams: []cfbEntry{summaryInformation(map[uint32]interface{}{
+		pidTitle:  "Quarterly report",
+		pidAuthor: "Jane Doe",
+	})},
+}
+
+func TestParseDocument(t *testing.T) {
+	d, err := ParseDocument(bytes.NewReader(richDoc.build()))
+	if err != nil {
+		t.Fatal("expected successful parse", err)
+	}
+	if d.Metadata.Title != "Quarterly report" || d.Metadata.Author != "Jane Doe" {
+		t.Errorf("expected metadata to be read, got %+v", d.Metadata)
+	}
+	if len(d.Paragraphs) != 4 {
+		t.Fatalf("expected 4 paragraphs, got %d", len(d.Paragraphs))
+	}
+	if p := d.Paragraphs[1]; len(p.Runs) != 2 || p.Text() != "Revenue grew in 中国 & \"EU\"" {
+		t.Errorf("expected two runs in second paragraph, got %+v", p)
+	}
+	if len(d.Paragraphs[2].Runs) != 0 {
+		t.Errorf("expected empty third paragraph, got %+v", d.Paragraphs[2])
+	}
+}
+
+func TestParseDocJSON(t *testing.T) {
+	actual, err := ParseDocJSON(bytes.NewReader(richDoc.build()))
+	if err != nil {
+		t.Fatal("expected successful parse", err)
+	}
+	expected, err := os.ReadFile(`testData/richDoc.json`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(actual, expected) {
+		t.Errorf("JSON mismatch. Expected:\n%s\nActual:\n%s", expected, actual)
+	}
+}
+
+func TestDocumentLanguages(t *testing.T) {
+	lid := func(sprm, lid uint16) []byte {
+		return binary.LittleEndian.AppendUint16(binary.LittleEndian.AppendUint16(nil, sprm), lid)
+	}
+	b := testDoc{pieces: []testPiece{
+		{text: "Hello, ", compressed: true, grpprl: lid(sprmCRgLid0, 0x0809)},
+		{text: "bonjour ", compressed: true, grpprl: lid(sprmCRgLid0_80, 0x040C)},
+		{text: "tout le monde\r", compressed: true},
+		{text: "中文\r", grpprl: append(lid(sprmCRgLid0, 0x0409), lid(sprmCRgLid1, 0x0804)...)},
+	}}.build()
+
+	d, err := ParseDocument(bytes.NewReader(b))
+	if err != nil {
+		t.Fatal("expected successful parse", err)
+	}
+	var langs []uint16
+	for _, run := range d.Paragraphs[0].Runs {
+		langs = append(langs, run.Lang)
+	}
+	if !reflect.DeepEqual(langs, []uint16{0x0809, 0x040C, 0x0409}) {
+		t.Errorf("expected run languages with fallback to the FIB, got %x", langs)
+	}
+
+	langs, err = DocumentLanguages(bytes.NewReader(b))
+	if err != nil {
+		t.Fatal("expected successful parse", err)
+	}
+	if !reflect.DeepEqual(langs, []uint16{0x0809, 0x040C, 0x0409, 0x0804}) {
+		t.Errorf("expected distinct languages, got %x", langs)
+	}
+}
+
+func TestFirstParagraph(t *testing.T) {
+	for name, test := range map[string]struct {
+		pieces   []testPiece
+		expected string
+	}{
+		"first":      {[]testPiece{{text: "Title line\rBody text\r", compressed: true}}, "Title line"},
+		"empty":      {[]testPiece{{text: "\r \t\r", compressed: true}, {text: "Заголовок\rТекст\r"}}, "Заголовок"},
+		"field":      {[]testPiece{{text: "\rPage \x13 PAGE \x141\x15 of 3\rmore\r", compressed: true}}, "Page 1 of 3"},
+		"no mark":    {[]testPiece{{text: "\rOnly line", compressed: true}}, "Only line"},
+		"blank only": {[]testPiece{{text: "\r\r ", compressed: true}}, ""},
+	} {
+		s, err := FirstParagraph(bytes.NewReader(testDoc{pieces: test.pieces}.build()))
+		if err != nil {
+			t.Fatal(name, err)
+		}
+		if s != test.expected {
+			t.Errorf("%s: expected %q, got %q", name, test.expected, s)
+		}
+	}
+}
+
+func TestFirstParagraphStopsEarly(t *testing.T) {
+	// the second of three pieces points past the end of the WordDocument
+	// stream, which ParseDoc rejects but FirstParagraph never reaches
+	raw := []byte{0x02, 40, 0, 0, 0}
+	for _, cp := range []uint32{0, 19, 20, 24} {
+		raw = binary.LittleEndian.AppendUint32(raw, cp)
+	}
+	for _, fc := range []uint32{0x400, 0x100000, 0x413} {
+		raw = append(raw, 0, 0)
+		raw = binary.LittleEndian.AppendUint32(raw, fc*2|0x40000000) // compressed
+		raw = append(raw, 0, 0)
+	}
+	b := testDoc{
+		pieces: []testPiece{{text: "\r \rTitle line\rBody\r", compressed: true}, {text: "tail\r", compressed: true}},
+		clx:    raw,
+	}.build()
+
+	if _, err := ParseDoc(bytes.NewReader(b)); !errors.Is(err, errInvalidArgument) {
+		t.Fatalf("expected errInvalidArgument from ParseDoc, got %v", err)
+	}
+	s, err := FirstParagraph(bytes.NewReader(b))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s != "Title line" {
+		t.Errorf("expected %q, got %q", "Title line", s)
+	}
+
+	// a walk halted at a paragraph's end translates nothing after its mark
+	pd, err := openDoc(bytes.NewReader(b), Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	w := newTextWriter(Options{})
+	stop := errors.New("stop")
+	w.walk = func(e RunEvent) error {
+		if e.Kind == ParagraphEnd {
+			return stop
+		}
+		return nil
+	}
+	if err := writeText(pd, w); err != nil {
+		t.Fatal(err)
+	}
+	if w.walkErr != stop || w.chars != 1 {
+		t.Errorf("expected the walk to stop after 1 character, got %d (%v)", w.chars, w.walkErr)
+	}
+}
@@ -0,0 +1,76 @@
+package doc
+
+import (
+	"encoding/binary"
+	"io"
+	"unicode/utf16"
+
+	"github.com/richardlehane/mscfb"
+)
+
+// docVar is a named value stored with the document, as read by
+// DOCVARIABLE fields
+type docVar struct {
+	name, value string
+}
+
+// DocVariables returns the document variables of the .doc file in r, which
+// templates and mail merges set and DOCVARIABLE fields display, by name.
+// Documents without variables give an empty map.
+func DocVariables(r io.Reader) (map[string]string, error) {
+	ra, release, err := readerAt(r, Options{})
+	if err != nil {
+		return nil, wrapError(err)
+	}
+	defer release()
+
+	pd, err := openDoc(ra, Options{})
+	if err != nil {
+		return nil, wrapError(err)
+	}
+	vars, err := getDocVars(pd.table, pd.fib)
+	if err != nil {
+		return nil, wrapError(err)
+	}
+	m := make(map[string]string, len(vars))
+	for _, v := range vars {
+		m[v.name] = v.value
+	}
+	return m, nil
+}
+
+// getDocVars reads the document variables from the StwUser
+func getDocVars(table *mscfb.File, fib *fib) ([]docVar, error) {
+	b, err := readTableBytes(table, fib.fibRgFcLcb.fcStwUser, fib.fibRgFcLcb.lcbStwUser)
+	if err != nil || b == nil {
+		return nil, err
+	}
+	return parseStwUser(b)
+}
+
+// parseStwUser parses a StwUser (section 2.9.286): an STTB of the variable
+// names followed by an Xst with the value of each
+func parseStwUser(b []byte) ([]docVar, error) {
+	names, b, err := parseSttb(b)
+	if err != nil {
+		return nil, err
+	}
+
+	vars := make([]docVar, 0, len(names))
+	for _, name := range names {
+		if len(b) < 2 {
+			return nil, errInvalidSttb
+		}
+		cch := int(binary.LittleEndian.Uint16(b))
+		if len(b) < 2+cch*2 {
+			return nil, errInvalidSttb
+		}
+		units := make([]uint16, cch)
+		for i := range units {
+			units[i] = binary.LittleEndian.Uint16(b[2+i*2:])
+		}
+		vars = append(vars, docVar{name: name, value: string(utf16.Decode(units))})
+		b = b[2+cch*2:]
+	}
+	return vars, nil
+}
@@ -0,0 +1,41 @@
+package doc
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestDocVariables(t *testing.T) {
+	b := testDoc{
+		pieces:  []testPiece{{text: "Dear \x13 DOCVARIABLE Customer \x14Ms Müller\x15\r", compressed: true}},
+		docVars: []docVar{{"Customer", "Ms Müller"}, {"Ticket", "№ 4711"}},
+	}.build()
+	vars, err := DocVariables(bytes.NewReader(b))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string]string{"Customer": "Ms Müller", "Ticket": "№ 4711"}
+	if !reflect.DeepEqual(vars, expected) {
+		t.Errorf("expected %q, got %q", expected, vars)
+	}
+
+	vars, err = DocVariables(bytes.NewReader(testDoc{pieces: []testPiece{{text: "text\r", compressed: true}}}.build()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if vars == nil || len(vars) != 0 {
+		t.Errorf("expected an empty map, got %#v", vars)
+	}
+}
+
+func TestDocVariablesTruncated(t *testing.T) {
+	// a value cut short by lcbStwUser
+	stw := []byte{0xFF, 0xFF, 1, 0, 0, 0}
+	stw = appendXst(stw, "Name")
+	stw = append(stw, 5, 0, 'a', 0)
+	if _, err := parseStwUser(stw); !errors.Is(err, errInvalidSttb) {
+		t.Errorf("expected errInvalidSttb, got %v", err)
+	}
+}
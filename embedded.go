@@ -0,0 +1,56 @@
+package doc
+
+import (
+	"errors"
+	"io"
+	"strings"
+
+	"github.com/richardlehane/mscfb"
+)
+
+var (
+	// ErrNotEmbeddedDoc is returned by ParseEmbedded when the storage path
+	// doesn't exist or holds no WordDocument stream
+	ErrNotEmbeddedDoc = errors.New("storage is not an embedded Word document")
+)
+
+// ParseEmbedded extracts the text of a Word document embedded as an OLE
+// object in the .doc file in r. streamPath names the storage holding it,
+// with path elements separated by "/", e.g. "ObjectPool/_1234567890".
+func ParseEmbedded(r io.Reader, streamPath string) (io.Reader, error) {
+	ra, release, err := readerAt(r, Options{})
+	if err != nil {
+		return nil, wrapError(err)
+	}
+	defer release()
+
+	d, err := newCFB(ra)
+	if err != nil {
+		return nil, wrapError(err)
+	}
+
+	path := strings.Split(strings.Trim(streamPath, "/"), "/")
+	if !isStorage(d, path) {
+		return nil, wrapError(ErrNotEmbeddedDoc)
+	}
+	if wordDoc, _, _ := getWordDocAndTablesAt(d, path, nil); wordDoc == nil {
+		return nil, wrapError(ErrNotEmbeddedDoc)
+	}
+
+	pd, err := openStorage(d, path, Options{})
+	if err != nil {
+		return nil, wrapError(err)
+	}
+	pd.size = inputSize(ra)
+	return getText(pd, Options{})
+}
+
+// isStorage reports whether path names a storage in d
+func isStorage(d *mscfb.Reader, path []string) bool {
+	for _, f := range d.File {
+		if f.FileInfo().IsDir() && f.Name == path[len(path)-1] && samePath(f.Path, path[:len(path)-1]) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,39 @@
+package doc
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func TestParseEmbedded(t *testing.T) {
+	inner := testDoc{pieces: []testPiece{{text: "Embedded text\r", compressed: true}}}
+	outer := testDoc{
+		pieces: []testPiece{{text: "Outer text\r", compressed: true}},
+		streams: []cfbEntry{{name: "ObjectPool", storage: true, children: []cfbEntry{
+			{name: "_1234567890", storage: true, children: inner.entries()},
+		}}},
+	}.build()
+
+	buf, err := ParseEmbedded(bytes.NewReader(outer), "ObjectPool/_1234567890")
+	if err != nil {
+		t.Fatal("expected successful parse", err)
+	}
+	if s := buf.(*bytes.Buffer).String(); s != "Embedded text\r" {
+		t.Errorf("expected correct value |%s|", s)
+	}
+
+	buf, err = ParseDoc(bytes.NewReader(outer))
+	if err != nil {
+		t.Fatal("expected successful parse", err)
+	}
+	if s := buf.(*bytes.Buffer).String(); s != "Outer text\r" {
+		t.Errorf("expected embedded streams to be ignored |%s|", s)
+	}
+
+	for _, path := range []string{"ObjectPool", "ObjectPool/_missing", "WordDocument"} {
+		if _, err := ParseEmbedded(bytes.NewReader(outer), path); !errors.Is(err, ErrNotEmbeddedDoc) {
+			t.Errorf("expected ErrNotEmbeddedDoc for %s, got %v", path, err)
+		}
+	}
+}
@@ -0,0 +1,67 @@
+package doc
+
+import "io"
+
+// ExtractFields selects what Extract reads besides the text
+type ExtractFields uint
+
+// Fields for Options.Extract
+const (
+	ExtractMetadata ExtractFields = 1 << iota
+	ExtractTables
+	ExtractHyperlinks
+	ExtractAll = ExtractMetadata | ExtractTables | ExtractHyperlinks
+)
+
+// Result holds what Extract read from a document. Fields that weren't
+// selected in Options.Extract are left empty.
+type Result struct {
+	// Text is the text ParseDocWithOptions would return
+	Text       string
+	Metadata   *Metadata
+	Tables     []Table
+	Hyperlinks []Hyperlink
+}
+
+// Extract reads the text of the .doc file in r along with the parts of
+// the document selected by opts.Extract, opening the file and walking its
+// pieces once
+func Extract(r io.Reader, opts Options) (*Result, error) {
+	ra, release, err := readerAt(r, opts)
+	if err != nil {
+		return nil, wrapError(err)
+	}
+	defer release()
+
+	pd, err := openDoc(ra, opts)
+	if err != nil {
+		return nil, wrapError(err)
+	}
+
+	res := &Result{}
+	if opts.Extract&ExtractMetadata != 0 {
+		if res.Metadata, err = getMetadata(pd.cfb); err != nil {
+			return nil, wrapError(err)
+		}
+	}
+
+	w := newTextWriter(opts)
+	if opts.Extract&ExtractTables != 0 {
+		w.tables = &tableBuilder{}
+	}
+	w.collectLinks = opts.Extract&ExtractHyperlinks != 0
+	if err := writeText(pd, w); err != nil {
+		return nil, wrapError(err)
+	}
+	w.finishText(opts)
+	res.Text = w.buf.String()
+
+	if w.tables != nil {
+		w.tables.end()
+		res.Tables = append([]Table{}, w.tables.tables...)
+	}
+	if w.collectLinks {
+		res.Hyperlinks = append([]Hyperlink{}, w.links...)
+	}
+	return res, nil
+}
@@ -0,0 +1,127 @@
+package doc
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// richTestDoc has metadata, a two-row table and two hyperlinks
+func richTestDoc() []byte {
+	inTable := []byte{0x16, 0x24, 0x01}                  // sprmPFInTable
+	rowEnd := []byte{0x16, 0x24, 0x01, 0x17, 0x24, 0x01} // and sprmPFTtp
+	return testDoc{
+		pieces: []testPiece{
+			{text: "Prices\r", compressed: true},
+			{text: "Item\x07Price\x07", compressed: true, papx: inTable},
+			{text: "\x07", compressed: true, papx: rowEnd},
+			{text: "Tea\x07€3\x07", compressed: true, papx: inTable},
+			{text: "\x07", compressed: true, papx: rowEnd},
+			{text: "See \x13 HYPERLINK \"http://example.com/tea\" \\o \"Tea\" \x14our shop\x15 or ", compressed: true},
+			{text: "\x13HYPERLINK \\l \"prices\"\x14this table\x15. \x13 PAGE \x141\x15\r"},
+		},
+		streams: []cfbEntry{summaryInformation(map[uint32]interface{}{
+			pidTitle:  "Price list",
+			pidAuthor: "Jane Doe",
+		})},
+	}.build()
+}
+
+func TestExtract(t *testing.T) {
+	res, err := Extract(bytes.NewReader(richTestDoc()), Options{Extract: ExtractAll})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	const text = "Prices\rItem Price  Tea €3  See our shop or this table. 1\r"
+	if res.Text != text {
+		t.Errorf("expected text %q, got %q", text, res.Text)
+	}
+	if res.Metadata == nil || res.Metadata.Title != "Price list" || res.Metadata.Author != "Jane Doe" {
+		t.Errorf("unexpected metadata %+v", res.Metadata)
+	}
+	tables := []Table{{Rows: [][]string{{"Item", "Price"}, {"Tea", "€3"}}}}
+	if !reflect.DeepEqual(res.Tables, tables) {
+		t.Errorf("expected tables %q, got %q", tables, res.Tables)
+	}
+	links := []Hyperlink{
+		{URL: "http://example.com/tea", Text: "our shop"},
+		{URL: "#prices", Text: "this table"},
+	}
+	if !reflect.DeepEqual(res.Hyperlinks, links) {
+		t.Errorf("expected hyperlinks %+v, got %+v", links, res.Hyperlinks)
+	}
+}
+
+func TestExtractTextOnly(t *testing.T) {
+	res, err := Extract(bytes.NewReader(richTestDoc()), Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	text, err := ParseDoc(bytes.NewReader(richTestDoc()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Text != text.(*bytes.Buffer).String() {
+		t.Errorf("expected the text ParseDoc returns, got %q", res.Text)
+	}
+	if res.Metadata != nil || res.Tables != nil || res.Hyperlinks != nil {
+		t.Errorf("expected only text, got %+v", res)
+	}
+}
+
+func TestExtractTextMatchesParse(t *testing.T) {
+	for _, opts := range []Options{
+		{CollapseWhitespace: true},
+		{TrimTrailing: true, LineEnding: LF},
+		{DropFinalMark: true, CollapseWhitespace: true, TrimTrailing: true},
+	} {
+		opts.Extract = ExtractAll
+		res, err := Extract(bytes.NewReader(richTestDoc()), opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		text, err := ParseDocWithOptions(bytes.NewReader(richTestDoc()), opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if s := text.(*bytes.Buffer).String(); res.Text != s {
+			t.Errorf("%+v: expected the text ParseDocWithOptions returns, %q, got %q", opts, s, res.Text)
+		}
+	}
+}
+
+func TestParseCellSeparator(t *testing.T) {
+	for _, test := range []struct {
+		opts Options
+		want string
+	}{
+		{Options{}, "Prices\rItem Price  Tea €3  See"},
+		{Options{CellSeparator: "\t"}, "Prices\rItem\tPrice\t\nTea\t€3\t\nSee"},
+		{Options{CellSeparator: " | ", LineEnding: CRLF}, "Prices\r\nItem | Price | \r\nTea | €3 | \r\nSee"},
+	} {
+		buf, err := ParseDocWithOptions(bytes.NewReader(richTestDoc()), test.opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if s := buf.(*bytes.Buffer).String(); !strings.HasPrefix(s, test.want) {
+			t.Errorf("expected text starting %q, got %q", test.want, s)
+		}
+	}
+}
+
+func TestParseLinkFormat(t *testing.T) {
+	for format, want := range map[LinkFormat]string{
+		LinkText:    "See our shop or this table. 1\r",
+		LinkTextURL: "See our shop <http://example.com/tea> or this table <#prices>. 1\r",
+	} {
+		buf, err := ParseDocWithOptions(bytes.NewReader(richTestDoc()), Options{LinkFormat: format})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if s := buf.(*bytes.Buffer).String(); !strings.HasSuffix(s, want) {
+			t.Errorf("format %d: expected text ending %q, got %q", format, want, s)
+		}
+	}
+}
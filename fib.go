@@ -3,14 +3,40 @@ package doc
 import (
 	"encoding/binary"
 	"errors"
+	"fmt"
+	"io"
+	"time"
 
 	"github.com/richardlehane/mscfb"
 )
 
 var (
+	// ErrUnsupportedVersion is matched by the *VersionError returned for
+	// documents from Word versions before Word 97, whose FIB this package
+	// can't read
+	ErrUnsupportedVersion = errors.New("unsupported Word version")
+
 	errFibInvalid = errors.New("file information block validation failed")
 )
 
+// minNFib is the FibBase.nFib of Word 97 documents. Earlier versions store
+// a different FIB and no piece table in a table stream.
+const minNFib = 0x00C0
+
+// VersionError reports the FibBase.nFib of a document this package can't
+// read. It matches ErrUnsupportedVersion with errors.Is.
+type VersionError struct {
+	NFib uint16
+}
+
+func (e *VersionError) Error() string {
+	return fmt.Sprintf("%v: nFib 0x%04X", ErrUnsupportedVersion, e.NFib)
+}
+
+func (e *VersionError) Is(target error) bool {
+	return target == ErrUnsupportedVersion
+}
+
 type fib struct {
 	base       fibBase
 	csw        int
@@ -22,7 +48,13 @@ type fib struct {
 }
 
 type fibBase struct {
+	nFib         uint16
+	lid          int
+	fComplex     bool
 	fWhichTblStm int
+	fExtChar     bool
+	fcMin        int // start of the text in WordDocument, for documents without a Clx
+	fcMac        int // end of that text
 }
 
 type fibRgW struct {
@@ -41,16 +73,136 @@ type fibRgLw struct {
 }
 
 type fibRgFcLcb struct {
-	fcPlcfFldMom  int
-	lcbPlcfFldMom int
-	fcPlcfFldHdr  int
-	lcbPlcfFldHdr int
-	fcPlcfFldFtn  int
-	lcbPlcfFldFtn int
-	fcPlcfFldAtn  int
-	lcbPlcfFldAtn int
-	fcClx         int
-	lcbClx        int
+	fcStshf        int
+	lcbStshf       int
+	fcPlcfSed      int
+	lcbPlcfSed     int
+	fcPlcfBteChpx  int
+	lcbPlcfBteChpx int
+	fcPlcfBtePapx  int
+	lcbPlcfBtePapx int
+	fcPlcfFldMom   int
+	lcbPlcfFldMom  int
+	fcPlcfFldHdr   int
+	lcbPlcfFldHdr  int
+	fcPlcfFldFtn   int
+	lcbPlcfFldFtn  int
+	fcPlcfFldAtn   int
+	lcbPlcfFldAtn  int
+	fcSttbfBkmk    int
+	lcbSttbfBkmk   int
+	fcPlcfBkf      int
+	lcbPlcfBkf     int
+	fcPlcfBkl      int
+	lcbPlcfBkl     int
+	fcDop          int
+	lcbDop         int
+	fcClx          int
+	lcbClx         int
+	fcPlcftxbxTxt  int
+	lcbPlcftxbxTxt int
+	fcStwUser      int
+	lcbStwUser     int
+
+	fcPlfLst         int
+	lcbPlfLst        int
+	fcSttbListNames  int
+	lcbSttbListNames int
+}
+
+// FIBInfo exposes details of a document's File Information Block that help
+// explain how reliably its text can be extracted
+type FIBInfo struct {
+	// FastSaved is set when the document was last saved incrementally
+	// (FibBase.fComplex). Fast-saved documents keep edits in extra pieces
+	// with text stored out of order.
+	FastSaved bool
+	// Pieces is the number of pieces in the piece table
+	Pieces int
+	// ExtChar is set (FibBase.fExtChar) by Word 97 and later. When it is
+	// clear, compressed text is in the code page of the document's language
+	// rather than Windows-1252, and is decoded accordingly.
+	ExtChar bool
+	// Created and Revised are when the document was created and last
+	// saved, as recorded in its document properties (Dop) alongside the
+	// FIB. They are in the local time of the machine that saved the
+	// document, given in UTC, and zero when unset.
+	Created time.Time
+	Revised time.Time
+}
+
+// ReadFIBInfo parses the FIB and piece table of the .doc file in r
+// without extracting its text
+func ReadFIBInfo(r io.Reader) (*FIBInfo, error) {
+	ra, release, err := readerAt(r, Options{})
+	if err != nil {
+		return nil, wrapError(err)
+	}
+	defer release()
+
+	pd, err := openDoc(ra, Options{})
+	if err != nil {
+		return nil, wrapError(err)
+	}
+	info := &FIBInfo{FastSaved: pd.fib.base.fComplex, Pieces: len(pd.clx.pcdt.PlcPcd.aPcd), ExtChar: pd.fib.base.fExtChar}
+	// dttmCreated and dttmRevised in DopBase (section 2.7.5)
+	if dop, err := readTableBytes(pd.table, pd.fib.fibRgFcLcb.fcDop, pd.fib.fibRgFcLcb.lcbDop); err == nil && len(dop) >= 0x1C {
+		info.Created = parseDTTM(binary.LittleEndian.Uint32(dop[0x14:]))
+		info.Revised = parseDTTM(binary.LittleEndian.Uint32(dop[0x18:]))
+	}
+	return info, nil
+}
+
+// parseDTTM decodes a DTTM (section 2.9.66), giving the zero time when it
+// is unset or invalid
+func parseDTTM(dttm uint32) time.Time {
+	minute := int(dttm & 0x3F)
+	hour := int(dttm >> 6 & 0x1F)
+	day := int(dttm >> 11 & 0x1F)
+	month := int(dttm >> 16 & 0x0F)
+	year := 1900 + int(dttm>>20&0x1FF)
+	if dttm == 0 || minute > 59 || hour > 23 || day < 1 || month < 1 || month > 12 || day > daysIn(month, year) {
+		return time.Time{}
+	}
+	return time.Date(year, time.Month(month), day, hour, minute, 0, 0, time.UTC)
+}
+
+// daysIn returns the number of days in month of year
+func daysIn(month, year int) int {
+	return time.Date(year, time.Month(month)+1, 0, 0, 0, 0, 0, time.UTC).Day()
+}
+
+// IsFastSaved reports whether the .doc file in r was fast-saved
+func IsFastSaved(r io.Reader) (bool, error) {
+	info, err := ReadFIBInfo(r)
+	if err != nil {
+		return false, err
+	}
+	return info.FastSaved, nil
+}
+
+// CompressionStats counts the pieces of the .doc file in r holding
+// compressed (8-bit) text and those holding Unicode text. A document
+// without compressed pieces decodes the same whatever the code page.
+func CompressionStats(r io.Reader) (compressedPieces, unicodePieces int, err error) {
+	ra, release, err := readerAt(r, Options{})
+	if err != nil {
+		return 0, 0, wrapError(err)
+	}
+	defer release()
+
+	pd, err := openDoc(ra, Options{})
+	if err != nil {
+		return 0, 0, wrapError(err)
+	}
+	for _, pcd := range pd.clx.pcdt.PlcPcd.aPcd {
+		if pcd.fc.fCompressed {
+			compressedPieces++
+		} else {
+			unicodePieces++
+		}
+	}
+	return compressedPieces, unicodePieces, nil
 }
 
 // parse File Information Block (section 2.5.1)
@@ -60,12 +212,21 @@ func getFib(wordDoc *mscfb.File) (*fib, error) {
 	}
 
 	b := make([]byte, 898) // get FIB block up to FibRgFcLcb97
-	_, err := wordDoc.ReadAt(b, 0)
+	if wordDoc.Size < 32 {
+		return nil, errDocShort
+	}
+	_, err := wordDoc.ReadAt(b[:min(int64(len(b)), wordDoc.Size)], 0)
 	if err != nil {
 		return nil, err
 	}
 
 	fibBase := getFibBase(b[0:32])
+	if fibBase.nFib < minNFib {
+		return nil, &VersionError{NFib: fibBase.nFib}
+	}
+	if wordDoc.Size < int64(len(b)) {
+		return nil, errDocShort
+	}
 
 	fibRgW, csw, err := getFibRgW(b, 32)
 	if err != nil {
@@ -84,9 +245,15 @@ func getFib(wordDoc *mscfb.File) (*fib, error) {
 
 // parse FibBase (section 2.5.2)
 func getFibBase(fib []byte) *fibBase {
+	nFib := uint16(getInt16(fib, 2))  // version of the file format
+	lid := getInt16(fib, 6)           // language of the text stored in the document
+	fComplex := fib[10]&0x04 != 0     // fComplex is the 3rd bit, set by an incremental (fast) save
 	byt := fib[11]                    // fWhichTblStm is 2nd highest bit in this byte
 	fWhichTblStm := int(byt >> 1 & 1) // set which table (0Table or 1Table) is the table stream
-	return &fibBase{fWhichTblStm: fWhichTblStm}
+	fExtChar := byt&0x10 != 0         // clear when 8-bit text is in the code page of lid
+	fcMin := getInt(fib, 24)          // reserved3, which older writers set to the start of the text
+	fcMac := getInt(fib, 28)          // reserved4, the end of the text
+	return &fibBase{nFib: nFib, lid: lid, fComplex: fComplex, fWhichTblStm: fWhichTblStm, fExtChar: fExtChar, fcMin: fcMin, fcMac: fcMac}
 }
 
 func getFibRgW(fib []byte, start int) (*fibRgW, int, error) {
@@ -129,11 +296,19 @@ func getFibRgLw(fib []byte, start int) (*fibRgLw, int, error) {
 // parse FibRgFcLcb (section 2.5.5)
 func getFibRgFcLcb(fib []byte, start int) (*fibRgFcLcb, int, error) {
 	fibRgFcLcbStart := start + 2          // skip cbRgFcLcb
-	if fibRgFcLcbStart+186*4 < len(fib) { // expect 186+ values in FibRgFcLcb
+	if fibRgFcLcbStart+186*4 > len(fib) { // expect 186+ values in FibRgFcLcb
 		return &fibRgFcLcb{}, 0, errFibInvalid
 	}
 
 	cbRgFcLcb := getInt16(fib, start)
+	fcStshf := getInt(fib, fibRgFcLcbStart+2*4)
+	lcbStshf := getInt(fib, fibRgFcLcbStart+3*4)
+	fcPlcfSed := getInt(fib, fibRgFcLcbStart+12*4)
+	lcbPlcfSed := getInt(fib, fibRgFcLcbStart+13*4)
+	fcPlcfBteChpx := getInt(fib, fibRgFcLcbStart+24*4)
+	lcbPlcfBteChpx := getInt(fib, fibRgFcLcbStart+25*4)
+	fcPlcfBtePapx := getInt(fib, fibRgFcLcbStart+26*4)
+	lcbPlcfBtePapx := getInt(fib, fibRgFcLcbStart+27*4)
 	fcPlcfFldMom := getInt(fib, fibRgFcLcbStart+32*4)
 	lcbPlcfFldMom := getInt(fib, fibRgFcLcbStart+33*4)
 	fcPlcfFldHdr := getInt(fib, fibRgFcLcbStart+34*4)
@@ -142,11 +317,33 @@ func getFibRgFcLcb(fib []byte, start int) (*fibRgFcLcb, int, error) {
 	lcbPlcfFldFtn := getInt(fib, fibRgFcLcbStart+37*4)
 	fcPlcfFldAtn := getInt(fib, fibRgFcLcbStart+38*4)
 	lcbPlcfFldAtn := getInt(fib, fibRgFcLcbStart+39*4)
+	fcSttbfBkmk := getInt(fib, fibRgFcLcbStart+42*4)
+	lcbSttbfBkmk := getInt(fib, fibRgFcLcbStart+43*4)
+	fcPlcfBkf := getInt(fib, fibRgFcLcbStart+44*4)
+	lcbPlcfBkf := getInt(fib, fibRgFcLcbStart+45*4)
+	fcPlcfBkl := getInt(fib, fibRgFcLcbStart+46*4)
+	lcbPlcfBkl := getInt(fib, fibRgFcLcbStart+47*4)
+	fcDop := getInt(fib, fibRgFcLcbStart+62*4)
+	lcbDop := getInt(fib, fibRgFcLcbStart+63*4)
 	fcClx := getInt(fib, fibRgFcLcbStart+66*4)
 	lcbClx := getInt(fib, fibRgFcLcbStart+67*4)
-	return &fibRgFcLcb{fcPlcfFldMom: fcPlcfFldMom, lcbPlcfFldMom: lcbPlcfFldMom, fcPlcfFldHdr: fcPlcfFldHdr, lcbPlcfFldHdr: lcbPlcfFldHdr,
+	fcPlcftxbxTxt := getInt(fib, fibRgFcLcbStart+112*4)
+	lcbPlcftxbxTxt := getInt(fib, fibRgFcLcbStart+113*4)
+	fcStwUser := getInt(fib, fibRgFcLcbStart+120*4)
+	lcbStwUser := getInt(fib, fibRgFcLcbStart+121*4)
+	fcPlfLst := getInt(fib, fibRgFcLcbStart+146*4)
+	lcbPlfLst := getInt(fib, fibRgFcLcbStart+147*4)
+	fcSttbListNames := getInt(fib, fibRgFcLcbStart+182*4)
+	lcbSttbListNames := getInt(fib, fibRgFcLcbStart+183*4)
+	return &fibRgFcLcb{fcStshf: fcStshf, lcbStshf: lcbStshf, fcPlcfSed: fcPlcfSed, lcbPlcfSed: lcbPlcfSed,
+		fcPlcfBteChpx: fcPlcfBteChpx, lcbPlcfBteChpx: lcbPlcfBteChpx, fcPlcfBtePapx: fcPlcfBtePapx, lcbPlcfBtePapx: lcbPlcfBtePapx,
+		fcPlcfFldMom: fcPlcfFldMom, lcbPlcfFldMom: lcbPlcfFldMom, fcPlcfFldHdr: fcPlcfFldHdr, lcbPlcfFldHdr: lcbPlcfFldHdr,
 		fcPlcfFldFtn: fcPlcfFldFtn, lcbPlcfFldFtn: lcbPlcfFldFtn, fcPlcfFldAtn: fcPlcfFldAtn, lcbPlcfFldAtn: lcbPlcfFldAtn,
-		fcClx: fcClx, lcbClx: lcbClx}, cbRgFcLcb, nil
+		fcSttbfBkmk: fcSttbfBkmk, lcbSttbfBkmk: lcbSttbfBkmk, fcPlcfBkf: fcPlcfBkf, lcbPlcfBkf: lcbPlcfBkf, fcPlcfBkl: fcPlcfBkl, lcbPlcfBkl: lcbPlcfBkl,
+		fcDop: fcDop, lcbDop: lcbDop,
+		fcClx: fcClx, lcbClx: lcbClx, fcPlcftxbxTxt: fcPlcftxbxTxt, lcbPlcftxbxTxt: lcbPlcftxbxTxt,
+		fcStwUser: fcStwUser, lcbStwUser: lcbStwUser,
+		fcPlfLst: fcPlfLst, lcbPlfLst: lcbPlfLst, fcSttbListNames: fcSttbListNames, lcbSttbListNames: lcbSttbListNames}, cbRgFcLcb, nil
 }
 
 func getInt16(buf []byte, start int) int {
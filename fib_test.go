@@ -0,0 +1,154 @@
+package doc
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestIsFastSaved(t *testing.T) {
+	fastSaved := testDoc{
+		fComplex: true,
+		pieces: []testPiece{
+			{text: "Original text\r", compressed: true},
+			{text: "inserted later ", compressed: true},
+			{text: "more\r", compressed: true},
+		},
+	}.build()
+	info, err := ReadFIBInfo(bytes.NewReader(fastSaved))
+	if err != nil {
+		t.Fatal("expected successful parse", err)
+	}
+	if !info.FastSaved || info.Pieces != 3 {
+		t.Errorf("expected fast-saved document with 3 pieces, got %+v", info)
+	}
+
+	f, err := os.Open(`testData/simpleDoc.doc`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	fast, err := IsFastSaved(f)
+	if err != nil {
+		t.Fatal("expected successful parse", err)
+	}
+	if fast {
+		t.Error("expected normally saved document")
+	}
+}
+
+func TestParseExtCharClear(t *testing.T) {
+	// Russian text stored as Windows-1251 bytes in a compressed piece
+	b := testDoc{lid: 0x0419, noExtChar: true, pieces: []testPiece{
+		{raw: []byte("\xcf\xf0\xe8\xe2\xe5\xf2, \xec\xe8\xf0\r"), compressed: true},
+	}}.build()
+
+	info, err := ReadFIBInfo(bytes.NewReader(b))
+	if err != nil {
+		t.Fatal("expected successful parse", err)
+	}
+	if info.ExtChar {
+		t.Error("expected fExtChar to be clear")
+	}
+	buf, err := ParseDoc(bytes.NewReader(b))
+	if err != nil {
+		t.Fatal("expected successful parse", err)
+	}
+	if s := buf.(*bytes.Buffer).String(); s != "Привет, мир\r" {
+		t.Errorf("expected text decoded as CP1251 |%s|", s)
+	}
+
+	info, err = ReadFIBInfo(bytes.NewReader(testDoc{pieces: []testPiece{{text: "x", compressed: true}}}.build()))
+	if err != nil {
+		t.Fatal("expected successful parse", err)
+	}
+	if !info.ExtChar {
+		t.Error("expected fExtChar to be set")
+	}
+}
+
+func TestParseUnsupportedVersion(t *testing.T) {
+	b := testDoc{pieces: []testPiece{{text: "Word 6\r", compressed: true}}, nFib: 0x0065}.build()
+
+	_, err := ParseDoc(bytes.NewReader(b))
+	if !errors.Is(err, ErrUnsupportedVersion) {
+		t.Fatalf("expected ErrUnsupportedVersion, got %v", err)
+	}
+	var verr *VersionError
+	if !errors.As(err, &verr) || verr.NFib != 0x0065 {
+		t.Errorf("expected a VersionError for nFib 0x0065, got %#v", err)
+	}
+	if !strings.Contains(err.Error(), "0x0065") {
+		t.Errorf("error %q doesn't name the version", err)
+	}
+}
+
+func TestCompressionStats(t *testing.T) {
+	for _, test := range []struct {
+		pieces              []testPiece
+		compressed, unicode int
+	}{
+		{[]testPiece{{text: "All ASCII. ", compressed: true}, {text: "Still ASCII.\r", compressed: true}}, 2, 0},
+		{[]testPiece{{text: "Unicode 中文\r"}}, 0, 1},
+		{[]testPiece{{text: "Mixed ", compressed: true}, {text: "текст "}, {text: "text\r", compressed: true}}, 2, 1},
+	} {
+		compressed, unicode, err := CompressionStats(bytes.NewReader(testDoc{pieces: test.pieces}.build()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if compressed != test.compressed || unicode != test.unicode {
+			t.Errorf("expected %d compressed and %d Unicode pieces, got %d and %d", test.compressed, test.unicode, compressed, unicode)
+		}
+	}
+}
+
+func TestReadFIBInfoDates(t *testing.T) {
+	// dttm packs minute, hour, day, month, years since 1900 and weekday
+	dttm := func(year, month, day, hour, minute, weekday int) uint32 {
+		return uint32(minute | hour<<6 | day<<11 | month<<16 | (year-1900)<<20 | weekday<<29)
+	}
+	dop := make([]byte, 0x22)
+	binary.LittleEndian.PutUint32(dop[0x14:], dttm(2019, 11, 2, 9, 5, 6))
+	binary.LittleEndian.PutUint32(dop[0x18:], dttm(2024, 3, 15, 14, 30, 5))
+	b := testDoc{pieces: []testPiece{{text: "text\r", compressed: true}}, dop: dop}.build()
+
+	info, err := ReadFIBInfo(bytes.NewReader(b))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := time.Date(2019, 11, 2, 9, 5, 0, 0, time.UTC); !info.Created.Equal(want) {
+		t.Errorf("expected creation time %v, got %v", want, info.Created)
+	}
+	if want := time.Date(2024, 3, 15, 14, 30, 0, 0, time.UTC); !info.Revised.Equal(want) {
+		t.Errorf("expected revision time %v, got %v", want, info.Revised)
+	}
+
+	// unset and invalid stamps, and documents without a Dop
+	binary.LittleEndian.PutUint32(dop[0x14:], 0)
+	binary.LittleEndian.PutUint32(dop[0x18:], dttm(2024, 2, 30, 0, 0, 0))
+	for _, d := range [][]byte{dop, nil} {
+		b = testDoc{pieces: []testPiece{{text: "text\r", compressed: true}}, dop: d}.build()
+		if info, err = ReadFIBInfo(bytes.NewReader(b)); err != nil {
+			t.Fatal(err)
+		}
+		if !info.Created.IsZero() || !info.Revised.IsZero() {
+			t.Errorf("expected zero times, got %v and %v", info.Created, info.Revised)
+		}
+	}
+	f, err := os.Open(`testData/docFile.doc`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if info, err = ReadFIBInfo(f); err != nil {
+		t.Fatal(err)
+	}
+	want := time.Date(2017, 8, 7, 16, 17, 0, 0, time.UTC)
+	if !info.Created.Equal(want) || !info.Revised.Equal(want) {
+		t.Errorf("expected docFile.doc to be created and revised at %v, got %v and %v", want, info.Created, info.Revised)
+	}
+}
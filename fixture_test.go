@@ -0,0 +1,716 @@
+package doc
+
+import (
+	"encoding/binary"
+	"sort"
+	"unicode/utf16"
+
+	"golang.org/x/text/encoding/charmap"
+)
+
+// The helpers in this file build small synthetic .doc files so tests can
+// exercise structures that the fixtures in testData don't contain.
+
+const (
+	cfbSectorSize     = 512
+	cfbMiniSectorSize = 64
+	cfbMiniCutoff     = 4096
+	cfbEndOfChain     = 0xFFFFFFFE
+	cfbFatSect        = 0xFFFFFFFD
+	cfbFreeSect       = 0xFFFFFFFF
+	cfbNoStream       = 0xFFFFFFFF
+)
+
+// cfbEntry is a stream (data) or storage (children) written by buildCFB
+type cfbEntry struct {
+	name     string
+	data     []byte
+	storage  bool
+	children []cfbEntry
+}
+
+type cfbDirEntry struct {
+	name               string
+	typ                byte
+	left, right, child uint32
+	start              uint32
+	size               uint32
+	data               []byte
+	mini               bool
+}
+
+// buildCFB writes a version 3 compound file holding entries at its root
+func buildCFB(entries []cfbEntry) []byte {
+	dirs := []*cfbDirEntry{{name: "Root Entry", typ: 5, left: cfbNoStream, right: cfbNoStream, child: cfbNoStream}}
+	var add func(parent int, children []cfbEntry)
+	add = func(parent int, children []cfbEntry) {
+		prev := -1
+		for _, c := range children {
+			id := len(dirs)
+			d := &cfbDirEntry{name: c.name, typ: 2, left: cfbNoStream, right: cfbNoStream, child: cfbNoStream, data: c.data}
+			if c.storage {
+				d.typ = 1
+			}
+			dirs = append(dirs, d)
+			if prev < 0 {
+				dirs[parent].child = uint32(id)
+			} else {
+				dirs[prev].right = uint32(id)
+			}
+			prev = id
+			if c.storage {
+				add(id, c.children)
+			}
+		}
+	}
+	add(0, entries)
+
+	// lay out the mini stream
+	var miniStream []byte
+	var miniFat []uint32
+	for _, d := range dirs {
+		if d.typ != 2 || len(d.data) == 0 {
+			d.start = cfbEndOfChain
+			d.size = uint32(len(d.data))
+			continue
+		}
+		d.size = uint32(len(d.data))
+		if len(d.data) >= cfbMiniCutoff {
+			continue
+		}
+		d.mini = true
+		d.start = uint32(len(miniFat))
+		n := (len(d.data) + cfbMiniSectorSize - 1) / cfbMiniSectorSize
+		for i := 0; i < n; i++ {
+			if i == n-1 {
+				miniFat = append(miniFat, cfbEndOfChain)
+			} else {
+				miniFat = append(miniFat, uint32(len(miniFat)+1))
+			}
+		}
+		miniStream = append(miniStream, pad(d.data, n*cfbMiniSectorSize)...)
+	}
+
+	sectors := func(n int) int { return (n + cfbSectorSize - 1) / cfbSectorSize }
+	numDir := sectors(len(dirs) * 128)
+	numMiniFat := sectors(len(miniFat) * 4)
+	numMiniStream := sectors(len(miniStream))
+	numStreams := 0
+	for _, d := range dirs {
+		if d.typ == 2 && !d.mini && len(d.data) > 0 {
+			numStreams += sectors(len(d.data))
+		}
+	}
+	nonFat := numDir + numMiniFat + numMiniStream + numStreams
+	numFat := 1
+	for numFat*cfbSectorSize/4 < numFat+nonFat {
+		numFat++
+	}
+
+	fat := make([]uint32, numFat*cfbSectorSize/4)
+	for i := range fat {
+		fat[i] = cfbFreeSect
+	}
+	next := 0
+	chain := func(n int) uint32 {
+		if n == 0 {
+			return cfbEndOfChain
+		}
+		start := next
+		for i := 0; i < n; i++ {
+			if i == n-1 {
+				fat[next] = cfbEndOfChain
+			} else {
+				fat[next] = uint32(next + 1)
+			}
+			next++
+		}
+		return uint32(start)
+	}
+	for i := 0; i < numFat; i++ {
+		fat[next] = cfbFatSect
+		next++
+	}
+	dirStart := chain(numDir)
+	miniFatStart := chain(numMiniFat)
+	miniStreamStart := chain(numMiniStream)
+
+	dirBytes := make([]byte, numDir*cfbSectorSize)
+	miniFatBytes := make([]byte, numMiniFat*cfbSectorSize)
+	for i := range miniFatBytes {
+		miniFatBytes[i] = 0xFF
+	}
+	for i, v := range miniFat {
+		binary.LittleEndian.PutUint32(miniFatBytes[i*4:], v)
+	}
+	var streamBytes []byte
+	for _, d := range dirs {
+		if d.typ == 2 && !d.mini && len(d.data) > 0 {
+			n := sectors(len(d.data))
+			d.start = chain(n)
+			streamBytes = append(streamBytes, pad(d.data, n*cfbSectorSize)...)
+		}
+	}
+	body := make([]byte, numFat*cfbSectorSize)
+	for i, v := range fat {
+		binary.LittleEndian.PutUint32(body[i*4:], v)
+	}
+
+	dirs[0].start = miniStreamStart
+	dirs[0].size = uint32(len(miniStream))
+	for i, d := range dirs {
+		e := dirBytes[i*128 : (i+1)*128]
+		name := utf16.Encode([]rune(d.name))
+		for j, u := range name {
+			binary.LittleEndian.PutUint16(e[j*2:], u)
+		}
+		binary.LittleEndian.PutUint16(e[64:], uint16((len(name)+1)*2))
+		e[66] = d.typ
+		e[67] = 1
+		binary.LittleEndian.PutUint32(e[68:], d.left)
+		binary.LittleEndian.PutUint32(e[72:], d.right)
+		binary.LittleEndian.PutUint32(e[76:], d.child)
+		binary.LittleEndian.PutUint32(e[116:], d.start)
+		binary.LittleEndian.PutUint32(e[120:], d.size)
+	}
+	for i := len(dirs); i < numDir*4; i++ {
+		e := dirBytes[i*128 : (i+1)*128]
+		binary.LittleEndian.PutUint32(e[68:], cfbNoStream)
+		binary.LittleEndian.PutUint32(e[72:], cfbNoStream)
+		binary.LittleEndian.PutUint32(e[76:], cfbNoStream)
+	}
+	body = append(body, dirBytes...)
+	body = append(body, miniFatBytes...)
+	body = append(body, pad(miniStream, numMiniStream*cfbSectorSize)...)
+	body = append(body, streamBytes...)
+
+	header := make([]byte, cfbSectorSize)
+	copy(header, []byte{0xD0, 0xCF, 0x11, 0xE0, 0xA1, 0xB1, 0x1A, 0xE1})
+	binary.LittleEndian.PutUint16(header[24:], 0x003E)
+	binary.LittleEndian.PutUint16(header[26:], 3)
+	binary.LittleEndian.PutUint16(header[28:], 0xFFFE)
+	binary.LittleEndian.PutUint16(header[30:], 9)
+	binary.LittleEndian.PutUint16(header[32:], 6)
+	binary.LittleEndian.PutUint32(header[44:], uint32(numFat))
+	binary.LittleEndian.PutUint32(header[48:], dirStart)
+	binary.LittleEndian.PutUint32(header[56:], cfbMiniCutoff)
+	binary.LittleEndian.PutUint32(header[60:], miniFatStart)
+	binary.LittleEndian.PutUint32(header[64:], uint32(numMiniFat))
+	binary.LittleEndian.PutUint32(header[68:], cfbEndOfChain)
+	for i := 0; i < 109; i++ {
+		v := uint32(cfbFreeSect)
+		if i < numFat {
+			v = uint32(i)
+		}
+		binary.LittleEndian.PutUint32(header[76+i*4:], v)
+	}
+	return append(header, body...)
+}
+
+func pad(b []byte, n int) []byte {
+	out := make([]byte, n)
+	copy(out, b)
+	return out
+}
+
+// testPiece is one entry of a synthetic piece table. raw, when set, is
+// stored verbatim instead of encoding text. grpprl holds the character
+// properties of the whole piece, papx and istd the paragraph properties and
+// style of the paragraph marks in it.
+type testPiece struct {
+	text       string
+	compressed bool
+	raw        []byte
+	grpprl     []byte
+	papx       []byte
+	istd       uint16
+}
+
+// testDoc describes a synthetic Word 97 document. Zero values give a
+// minimal valid document stored in 1Table.
+type testDoc struct {
+	pieces    []testPiece
+	table0    bool   // store the table stream as 0Table
+	fComplex  bool   // fast-saved flag in FibBase
+	nFib      uint16 // defaults to 0x00C1 (Word 97)
+	lid       uint16 // defaults to 0x0409 (en-US)
+	streams   []cfbEntry
+	noWordDoc bool
+	noTable   bool
+	noExtChar bool       // clear fExtChar, as in documents with 8-bit text in the lid's code page
+	sections  []int      // CP just past each section's last character
+	sepxs     [][]byte   // grpprl of each section's properties, nil for defaults
+	comments  []string   // text of each comment, stored after the main text
+	textboxes []string   // text of each text box, stored after the comments
+	bookmarks []bookmark // sorted by start and by end
+	docVars   []docVar   // document variables, stored in the StwUser
+	lists     listDefs   // named lists, stored in PlfLst and SttbListNames
+	styles    []style    // the stylesheet, indexed by istd
+	dop       []byte     // document properties (Dop)
+	clx       []byte     // replaces the generated Clx
+}
+
+const testTextOffset = 0x400 // text follows the 898-byte FIB
+
+// encode returns the bytes and CP count of a piece
+func (p testPiece) encode() ([]byte, int) {
+	if p.raw != nil {
+		if p.compressed {
+			return p.raw, len(p.raw)
+		}
+		return p.raw, len(p.raw) / 2
+	}
+	if p.compressed {
+		b, err := charmap.Windows1252.NewEncoder().Bytes([]byte(p.text))
+		if err != nil {
+			panic(err)
+		}
+		return b, len(b)
+	}
+	units := utf16.Encode([]rune(p.text))
+	b := make([]byte, len(units)*2)
+	for i, u := range units {
+		binary.LittleEndian.PutUint16(b[i*2:], u)
+	}
+	return b, len(units)
+}
+
+// build returns the compound file for d
+func (d testDoc) build() []byte {
+	return buildCFB(d.entries())
+}
+
+// entries returns the streams of d, to be stored at the root of a compound
+// file or inside a storage
+func (d testDoc) entries() []cfbEntry {
+	wordDoc := make([]byte, testTextOffset)
+	var cps []int
+	var fcs []uint32
+	var chpxFcs []int
+	var grpprls [][]byte
+	var papxs [][]byte
+	var hasChpx, hasPapx bool
+	cp := 0
+	// the textbox subdocument ends with an empty dummy text box, and the
+	// last subdocument with one more paragraph mark (section 2.8.35)
+	pieces := append([]testPiece{}, d.pieces...)
+	ccpText, ccpAtn := 0, 0
+	for _, p := range d.pieces {
+		_, n := p.encode()
+		ccpText += n
+	}
+	for _, text := range d.comments {
+		pieces = append(pieces, testPiece{text: text, compressed: true})
+		ccpAtn += len(text)
+	}
+	txbxCPs := []int{0}
+	if len(d.textboxes) > 0 {
+		for _, text := range append(append([]string{}, d.textboxes...), "\r") {
+			pieces = append(pieces, testPiece{text: text, compressed: true})
+			txbxCPs = append(txbxCPs, txbxCPs[len(txbxCPs)-1]+len(text))
+		}
+	}
+	if len(d.comments) > 0 || len(d.textboxes) > 0 {
+		pieces = append(pieces, testPiece{text: "\r", compressed: true})
+	}
+	for _, p := range pieces {
+		b, n := p.encode()
+		offset := len(wordDoc)
+		chpxFcs = append(chpxFcs, offset)
+		grpprls = append(grpprls, p.grpprl)
+		hasChpx = hasChpx || p.grpprl != nil
+		var grpPrlAndIstd []byte
+		if p.papx != nil || p.istd != 0 {
+			grpPrlAndIstd = append(binary.LittleEndian.AppendUint16(nil, p.istd), p.papx...)
+		}
+		papxs = append(papxs, grpPrlAndIstd)
+		hasPapx = hasPapx || grpPrlAndIstd != nil
+		if p.compressed {
+			fcs = append(fcs, uint32(offset*2)|0x40000000)
+		} else {
+			fcs = append(fcs, uint32(offset))
+		}
+		cps = append(cps, cp)
+		cp += n
+		wordDoc = append(wordDoc, b...)
+	}
+	cps = append(cps, cp)
+	chpxFcs = append(chpxFcs, len(wordDoc)) // piece boundaries, shared by both FKPs
+	var pnChpx, pnPapx int
+	if hasChpx {
+		pnChpx = (len(wordDoc) + 511) / 512
+		wordDoc = append(pad(wordDoc, pnChpx*512), chpxFkp(chpxFcs, grpprls)...)
+	}
+	if hasPapx {
+		pnPapx = (len(wordDoc) + 511) / 512
+		wordDoc = append(pad(wordDoc, pnPapx*512), papxFkp(chpxFcs, papxs)...)
+	}
+
+	// Clx containing a single Pcdt (section 2.9.38)
+	numPcds := len(pieces)
+	lcb := (numPcds+1)*4 + numPcds*8
+	clx := make([]byte, 5+lcb)
+	clx[0] = 0x02
+	binary.LittleEndian.PutUint32(clx[1:], uint32(lcb))
+	for i, c := range cps {
+		binary.LittleEndian.PutUint32(clx[5+i*4:], uint32(c))
+	}
+	pcdStart := 5 + (numPcds+1)*4
+	for i, fc := range fcs {
+		binary.LittleEndian.PutUint32(clx[pcdStart+i*8+2:], fc)
+	}
+	nFib := d.nFib
+	if nFib == 0 {
+		nFib = 0x00C1
+	}
+	lid := d.lid
+	if lid == 0 {
+		lid = 0x0409
+	}
+	binary.LittleEndian.PutUint16(wordDoc[0:], 0xA5EC)
+	binary.LittleEndian.PutUint16(wordDoc[2:], nFib)
+	binary.LittleEndian.PutUint16(wordDoc[6:], lid)
+	if d.fComplex {
+		wordDoc[10] |= 0x04
+	}
+	if !d.table0 {
+		wordDoc[11] |= 0x02
+	}
+	if !d.noExtChar {
+		wordDoc[11] |= 0x10
+	}
+	binary.LittleEndian.PutUint32(wordDoc[24:], uint32(testTextOffset))         // fcMin
+	binary.LittleEndian.PutUint32(wordDoc[28:], uint32(chpxFcs[len(d.pieces)])) // fcMac
+
+	binary.LittleEndian.PutUint16(wordDoc[32:], 14)                   // csw
+	binary.LittleEndian.PutUint16(wordDoc[62:], 22)                   // cslw
+	binary.LittleEndian.PutUint32(wordDoc[64:], uint32(len(wordDoc))) // cbMac
+	binary.LittleEndian.PutUint32(wordDoc[64+3*4:], uint32(ccpText))  // ccpText
+	binary.LittleEndian.PutUint32(wordDoc[64+7*4:], uint32(ccpAtn))   // ccpAtn
+	binary.LittleEndian.PutUint16(wordDoc[152:], 0x5D)                // cbRgFcLcb
+	if len(d.textboxes) > 0 {
+		binary.LittleEndian.PutUint32(wordDoc[64+9*4:], uint32(txbxCPs[len(txbxCPs)-1])) // ccpTxbx
+	}
+
+	// putTable appends b to the table stream and points the FibRgFcLcb97
+	// fc/lcb pair starting at slot to it
+	var table []byte
+	putTable := func(slot int, b []byte) {
+		binary.LittleEndian.PutUint32(wordDoc[154+slot*4:], uint32(len(table)))
+		binary.LittleEndian.PutUint32(wordDoc[154+(slot+1)*4:], uint32(len(b)))
+		table = append(table, b...)
+	}
+	if d.clx != nil {
+		clx = d.clx
+	}
+	putTable(66, clx)
+	if hasChpx {
+		pn := binary.LittleEndian.AppendUint32(nil, uint32(pnChpx))
+		putTable(24, plcBytes([]int{chpxFcs[0], chpxFcs[len(chpxFcs)-1]}, [][]byte{pn}))
+	}
+	if hasPapx {
+		pn := binary.LittleEndian.AppendUint32(nil, uint32(pnPapx))
+		putTable(26, plcBytes([]int{chpxFcs[0], chpxFcs[len(chpxFcs)-1]}, [][]byte{pn}))
+	}
+	if len(d.textboxes) > 0 {
+		ftxbxs := make([][]byte, len(txbxCPs)-1)
+		for i := range ftxbxs {
+			ftxbxs[i] = make([]byte, 22)
+		}
+		putTable(112, plcBytes(txbxCPs, ftxbxs))
+	}
+	if d.dop != nil {
+		putTable(62, d.dop)
+	}
+	if len(d.styles) > 0 {
+		stsh := binary.LittleEndian.AppendUint16(nil, 18) // cbStshi
+		stsh = binary.LittleEndian.AppendUint16(stsh, uint16(len(d.styles)))
+		stsh = binary.LittleEndian.AppendUint16(stsh, 10) // cbSTDBaseInFile: StdfBase only
+		stsh = append(stsh, make([]byte, 14)...)
+		for _, s := range d.styles {
+			std := binary.LittleEndian.AppendUint16(nil, uint16(s.sti))
+			std = append(std, make([]byte, 8)...)
+			units := utf16.Encode([]rune(s.name))
+			std = binary.LittleEndian.AppendUint16(std, uint16(len(units)))
+			for _, u := range units {
+				std = binary.LittleEndian.AppendUint16(std, u)
+			}
+			std = append(std, 0, 0)
+			stsh = binary.LittleEndian.AppendUint16(stsh, uint16(len(std)))
+			stsh = append(stsh, std...)
+		}
+		putTable(2, stsh)
+	}
+	if len(d.bookmarks) > 0 {
+		sttb := []byte{0xFF, 0xFF}
+		sttb = binary.LittleEndian.AppendUint16(sttb, uint16(len(d.bookmarks)))
+		sttb = binary.LittleEndian.AppendUint16(sttb, 0) // cbExtra
+		var starts, ends []int
+		var fbkfs [][]byte
+		for i, bk := range d.bookmarks {
+			units := utf16.Encode([]rune(bk.name))
+			sttb = binary.LittleEndian.AppendUint16(sttb, uint16(len(units)))
+			for _, u := range units {
+				sttb = binary.LittleEndian.AppendUint16(sttb, u)
+			}
+			starts = append(starts, bk.start)
+			ends = append(ends, bk.end)
+			fbkfs = append(fbkfs, binary.LittleEndian.AppendUint32(nil, uint32(i))) // ibkl, bkc
+		}
+		putTable(42, sttb)
+		putTable(44, plcBytes(append(starts, ccpText+1), fbkfs))
+		putTable(46, plcBytes(append(ends, ccpText+1), make([][]byte, len(ends))))
+	}
+	if len(d.docVars) > 0 {
+		stw := []byte{0xFF, 0xFF}
+		stw = binary.LittleEndian.AppendUint16(stw, uint16(len(d.docVars)))
+		stw = binary.LittleEndian.AppendUint16(stw, 0) // cbExtra
+		for _, v := range d.docVars {
+			stw = appendXst(stw, v.name)
+		}
+		for _, v := range d.docVars {
+			stw = appendXst(stw, v.value)
+		}
+		putTable(120, stw)
+	}
+	if len(d.lists) > 0 {
+		names := make([]string, 0, len(d.lists))
+		for name := range d.lists {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		sttb := []byte{0xFF, 0xFF}
+		sttb = binary.LittleEndian.AppendUint16(sttb, uint16(len(names)))
+		sttb = binary.LittleEndian.AppendUint16(sttb, 0) // cbExtra
+		plfLst := binary.LittleEndian.AppendUint16(nil, uint16(len(names)))
+		var lvls []byte
+		for _, name := range names {
+			sttb = appendXst(sttb, name)
+			def := d.lists[name]
+			lstf := make([]byte, 28)
+			if len(def) == 1 {
+				lstf[26] = 0x01 // fSimpleList
+			} else {
+				def = append(def, make(listDef, 9-len(def))...)
+			}
+			plfLst = append(plfLst, lstf...)
+			for _, lvl := range def {
+				lvlf := make([]byte, 28)
+				binary.LittleEndian.PutUint32(lvlf, uint32(lvl.start))
+				lvlf[4] = lvl.nfc
+				if lvl.legal {
+					lvlf[5] = 0x04
+				}
+				lvls = appendXst(append(lvls, lvlf...), lvl.text)
+			}
+		}
+		putTable(182, sttb)
+		putTable(146, plfLst)
+		table = append(table, lvls...) // the LVLs follow PlfLst
+	}
+	if len(d.sections) > 0 {
+		seds := make([][]byte, len(d.sections))
+		for i := range seds {
+			seds[i] = make([]byte, 12)
+			binary.LittleEndian.PutUint32(seds[i][2:], 0xFFFFFFFF) // fcSepx: default properties
+			if i < len(d.sepxs) && d.sepxs[i] != nil {
+				binary.LittleEndian.PutUint32(seds[i][2:], uint32(len(wordDoc)))
+				wordDoc = binary.LittleEndian.AppendUint16(wordDoc, uint16(len(d.sepxs[i])))
+				wordDoc = append(wordDoc, d.sepxs[i]...)
+			}
+		}
+		putTable(12, plcBytes(append([]int{0}, d.sections...), seds))
+	}
+
+	tableName := "1Table"
+	if d.table0 {
+		tableName = "0Table"
+	}
+	var entries []cfbEntry
+	if !d.noWordDoc {
+		entries = append(entries, cfbEntry{name: "WordDocument", data: wordDoc})
+	}
+	if !d.noTable {
+		entries = append(entries, cfbEntry{name: tableName, data: table})
+	}
+	entries = append(entries, d.streams...)
+	return entries
+}
+
+// chpxFkp builds a ChpxFkp page (section 2.9.33) for runs bounded by fcs
+// with the given grpprls, as long as they fit in one page. A nil grpprl
+// gives default properties.
+func chpxFkp(fcs []int, grpprls [][]byte) []byte {
+	page := make([]byte, 512)
+	crun := len(grpprls)
+	page[511] = byte(crun)
+	offset := 511
+	for i, grpprl := range grpprls {
+		if grpprl == nil {
+			continue
+		}
+		offset = (offset - 1 - len(grpprl)) &^ 1
+		page[offset] = byte(len(grpprl))
+		copy(page[offset+1:], grpprl)
+		page[(crun+1)*4+i] = byte(offset / 2)
+	}
+	for i, fc := range fcs {
+		binary.LittleEndian.PutUint32(page[i*4:], uint32(fc))
+	}
+	return page
+}
+
+// papxFkp builds a PapxFkp page (section 2.9.175) for paragraph runs
+// bounded by fcs with the given GrpPrlAndIstds
+func papxFkp(fcs []int, grpPrlAndIstds [][]byte) []byte {
+	page := make([]byte, 512)
+	cpara := len(grpPrlAndIstds)
+	page[511] = byte(cpara)
+	offset := 511
+	for i, grpPrlAndIstd := range grpPrlAndIstds {
+		if grpPrlAndIstd == nil {
+			continue
+		}
+		if len(grpPrlAndIstd)%2 != 0 {
+			grpPrlAndIstd = append(grpPrlAndIstd, 0)
+		}
+		offset = (offset - 2 - len(grpPrlAndIstd)) &^ 1
+		page[offset+1] = byte(len(grpPrlAndIstd) / 2) // after a zero cb
+		copy(page[offset+2:], grpPrlAndIstd)
+		page[(cpara+1)*4+i*13] = byte(offset / 2)
+	}
+	for i, fc := range fcs {
+		binary.LittleEndian.PutUint32(page[i*4:], uint32(fc))
+	}
+	return page
+}
+
+// plcBytes serializes a PLC (section 2.2.2)
+func plcBytes(cps []int, data [][]byte) []byte {
+	var b []byte
+	for _, cp := range cps {
+		b = binary.LittleEndian.AppendUint32(b, uint32(cp))
+	}
+	for _, d := range data {
+		b = append(b, d...)
+	}
+	return b
+}
+
+// appendXst appends s as a count of UTF-16 code units followed by the
+// units, the form of STTB strings and Xst values
+func appendXst(b []byte, s string) []byte {
+	units := utf16.Encode([]rune(s))
+	b = binary.LittleEndian.AppendUint16(b, uint16(len(units)))
+	for _, u := range units {
+		b = binary.LittleEndian.AppendUint16(b, u)
+	}
+	return b
+}
+
+// summaryInformation builds a SummaryInformation property set stream.
+// Values may be string (VT_LPSTR), int32 (VT_I4) or uint64 (VT_FILETIME).
+func summaryInformation(props map[uint32]interface{}) cfbEntry {
+	ids := []uint32{pidCodepage}
+	for id := range props {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids[1:], func(i, j int) bool { return ids[1+i] < ids[1+j] })
+
+	var values []byte
+	offsets := make([]uint32, len(ids))
+	base := 8 + len(ids)*8
+	for i, id := range ids {
+		offsets[i] = uint32(base + len(values))
+		v := make([]byte, 4)
+		switch val := props[id].(type) {
+		case nil: // code page
+			binary.LittleEndian.PutUint16(v, vtI2)
+			v = binary.LittleEndian.AppendUint32(v, 1252)
+		case string:
+			binary.LittleEndian.PutUint16(v, vtLPSTR)
+			s, _ := charmap.Windows1252.NewEncoder().Bytes([]byte(val))
+			s = append(s, 0)
+			v = binary.LittleEndian.AppendUint32(v, uint32(len(s)))
+			v = append(v, s...)
+		case int32:
+			binary.LittleEndian.PutUint16(v, vtI4)
+			v = binary.LittleEndian.AppendUint32(v, uint32(val))
+		case uint64:
+			binary.LittleEndian.PutUint16(v, vtFILETIME)
+			v = binary.LittleEndian.AppendUint64(v, val)
+		}
+		for len(v)%4 != 0 {
+			v = append(v, 0)
+		}
+		values = append(values, v...)
+	}
+
+	set := binary.LittleEndian.AppendUint32(nil, uint32(base+len(values)))
+	set = binary.LittleEndian.AppendUint32(set, uint32(len(ids)))
+	for i, id := range ids {
+		set = binary.LittleEndian.AppendUint32(set, id)
+		set = binary.LittleEndian.AppendUint32(set, offsets[i])
+	}
+	set = append(set, values...)
+
+	header := make([]byte, 48)
+	binary.LittleEndian.PutUint16(header, 0xFFFE)
+	binary.LittleEndian.PutUint32(header[24:], 1)
+	copy(header[28:], []byte{0xE0, 0x85, 0x9F, 0xF2, 0xF9, 0x4F, 0x68, 0x10, 0xAB, 0x91, 0x08, 0x00, 0x2B, 0x27, 0xB3, 0xD9})
+	binary.LittleEndian.PutUint32(header[44:], 48)
+	return cfbEntry{name: "\x05SummaryInformation", data: append(header, set...)}
+}
+
+// picture builds a PICFAndOfficeArtData (section 2.9.192) holding img in a
+// BLIP record of the given type and instance, for the Data stream
+func picture(recType, instance uint16, img []byte) []byte {
+	header := 16*(1+int(instance&1)) + 1 // rgbUid1, rgbUid2 for odd instances, tag
+	blip := make([]byte, 8+header)
+	binary.LittleEndian.PutUint16(blip, instance<<4)
+	binary.LittleEndian.PutUint16(blip[2:], recType)
+	binary.LittleEndian.PutUint32(blip[4:], uint32(header+len(img)))
+	blip = append(blip, img...)
+
+	fbse := make([]byte, 8+36)
+	binary.LittleEndian.PutUint16(fbse, 0x0002)
+	binary.LittleEndian.PutUint16(fbse[2:], 0xF007)
+	binary.LittleEndian.PutUint32(fbse[4:], uint32(36+len(blip)))
+	fbse = append(fbse, blip...)
+
+	spContainer := []byte{0x0F, 0x00, 0x04, 0xF0, 0, 0, 0, 0}
+	b := make([]byte, 0x44)
+	binary.LittleEndian.PutUint32(b, uint32(0x44+len(spContainer)+len(fbse)))
+	binary.LittleEndian.PutUint16(b[4:], 0x44) // cbHeader
+	binary.LittleEndian.PutUint16(b[6:], 0x64) // mm: MM_SHAPE
+	return append(append(b, spContainer...), fbse...)
+}
+
+// picLocation returns a grpprl marking a 0x01 character as the picture at
+// loc in the Data stream
+func picLocation(loc int) []byte {
+	grpprl := []byte{0x55, 0x08, 0x01, 0x03, 0x6A} // sprmCFSpec, sprmCPicLocation
+	return binary.LittleEndian.AppendUint32(grpprl, uint32(loc))
+}
+
+// clxBytes builds a Clx holding a single Pcdt (section 2.9.38) whose pieces
+// start at cps, with one more CP ending the last, and are stored at the
+// byte offsets in offsets, in compressed or UTF-16 text as flagged
+func clxBytes(cps []int, offsets []int, compressed []bool) []byte {
+	lcb := len(cps)*4 + len(offsets)*8
+	clx := []byte{0x02}
+	clx = binary.LittleEndian.AppendUint32(clx, uint32(lcb))
+	for _, cp := range cps {
+		clx = binary.LittleEndian.AppendUint32(clx, uint32(cp))
+	}
+	for i, offset := range offsets {
+		fc := uint32(offset)
+		if compressed[i] {
+			fc = fc*2 | 0x40000000
+		}
+		clx = binary.LittleEndian.AppendUint16(clx, 0)
+		clx = binary.LittleEndian.AppendUint32(clx, fc)
+		clx = binary.LittleEndian.AppendUint16(clx, 0)
+	}
+	return clx
+}
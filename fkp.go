@@ -0,0 +1,38 @@
+package doc
+
+import (
+	"encoding/binary"
+	"errors"
+
+	"github.com/richardlehane/mscfb"
+)
+
+var (
+	errInvalidFkp = errors.New("invalid formatted disk page")
+)
+
+// readFkps calls parse with each 512-byte formatted disk page listed in the
+// PlcBteChpx or PlcBtePapx at fc in the table stream (sections 2.8.6 and
+// 2.8.7). Both hold 4-byte page numbers in the WordDocument stream.
+func readFkps(wordDoc, table *mscfb.File, fc, lcb int, parse func(page []byte) error) error {
+	b, err := readTableBytes(table, fc, lcb)
+	if err != nil || b == nil {
+		return err
+	}
+	plcBte, err := parsePlc(b, 4)
+	if err != nil {
+		return err
+	}
+
+	page := make([]byte, 512)
+	for _, pnFkp := range plcBte.aData {
+		pn := int64(binary.LittleEndian.Uint32(pnFkp) & 0x3FFFFF)
+		if _, err := wordDoc.ReadAt(page, pn*512); err != nil {
+			return errInvalidFkp
+		}
+		if err := parse(page); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,40 @@
+package doc
+
+import (
+	"bytes"
+	"os"
+	"testing"
+)
+
+func FuzzParseDoc(f *testing.F) {
+	for _, name := range []string{`testData/simpleDoc.doc`, `testData/docFile.doc`} {
+		b, err := os.ReadFile(name)
+		if err != nil {
+			f.Fatal(err)
+		}
+		f.Add(b)
+	}
+	f.Add(richDoc.build())
+	f.Add(testDoc{
+		pieces: []testPiece{
+			{text: "Hé\x13 PAGE \x14llo\x15\x0c", compressed: true, grpprl: []byte{0x3C, 0x08, 0x01}},
+			{text: "中文字符\r"},
+		},
+		sections: []int{9},
+	}.build())
+
+	// piece tables that used to panic: empty, a truncated Prc, and CPs
+	// running backwards
+	pieces := []testPiece{{text: "text\r", compressed: true}}
+	f.Add(testDoc{pieces: pieces, clx: []byte{}}.build())
+	f.Add(testDoc{pieces: pieces, clx: []byte{0x01, 0x05}}.build())
+	backwards := []byte{0x02, 28, 0, 0, 0, 0, 0, 0, 0, 9, 0, 0, 0, 5, 0, 0, 0}
+	for i := 0; i < 2; i++ {
+		backwards = append(backwards, 0, 0, 0x00, 0x08, 0x00, 0x40, 0, 0)
+	}
+	f.Add(testDoc{pieces: pieces, clx: backwards}.build())
+
+	f.Fuzz(func(t *testing.T, b []byte) {
+		ParseDoc(bytes.NewReader(b))
+	})
+}
@@ -0,0 +1,131 @@
+package doc
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Hyperlink is a HYPERLINK field: where it points and the text it shows
+type Hyperlink struct {
+	// URL is the link target, ending in "#" and the bookmark name for
+	// links to a location within a document
+	URL  string `json:"url"`
+	Text string `json:"text"`
+}
+
+// fieldInstr collects the instructions of an open field, for hyperlinks
+// and auto-numbering fields
+type fieldInstr struct {
+	code        strings.Builder
+	url         string // set at the separator of a HYPERLINK field
+	resultStart int    // offset in buf of the field's result
+	resultChars int    // characters written before the field's result
+}
+
+// beginInstr starts collecting the instructions of a field
+func (w *textWriter) beginInstr() {
+	if !w.asciiOnly {
+		w.instrs = append(w.instrs, &fieldInstr{})
+	}
+}
+
+// fieldCode adds a character to the instructions of the innermost field
+func (w *textWriter) fieldCode(r rune) {
+	if n := len(w.instrs); n > 0 {
+		w.instrs[n-1].code.WriteRune(r)
+	}
+}
+
+// separateInstr handles the separator of the innermost field, after which
+// its result is written
+func (w *textWriter) separateInstr() {
+	if n := len(w.instrs); n > 0 {
+		f := w.instrs[n-1]
+		f.url = hyperlinkTarget(f.code.String())
+		f.resultStart = w.buf.Len()
+		f.resultChars = w.chars
+	}
+}
+
+// endInstr handles the end of the innermost field, recording it or
+// writing its target if it is a hyperlink with a result, and writing the
+// number of an auto-numbering field without one
+func (w *textWriter) endInstr(separated bool) {
+	n := len(w.instrs)
+	if n == 0 {
+		return
+	}
+	f := w.instrs[n-1]
+	w.instrs = w.instrs[:n-1]
+	if num, ok := w.listNumber(f.code.String()); ok && !w.inFieldCode() {
+		if !separated || w.chars == f.resultChars {
+			w.writeString(num)
+		}
+	}
+	if !separated || f.url == "" {
+		return
+	}
+	if w.collectLinks {
+		w.links = append(w.links, Hyperlink{URL: f.url, Text: string(w.buf.Bytes()[f.resultStart:])})
+	}
+	if w.inlineLinks {
+		w.writeString(" <" + f.url + ">")
+	}
+}
+
+// hyperlinkTarget returns the target of a field with instructions instr if
+// it is a HYPERLINK field, or ""
+func hyperlinkTarget(instr string) string {
+	args := fieldArgs(instr)
+	if len(args) == 0 || !strings.EqualFold(args[0], "HYPERLINK") {
+		return ""
+	}
+	var url, bookmark string
+	for i := 1; i < len(args); i++ {
+		switch strings.ToLower(args[i]) {
+		case `\l`:
+			if i+1 < len(args) {
+				i++
+				bookmark = args[i]
+			}
+		case `\o`, `\t`: // tooltip and target frame
+			i++
+		case `\m`, `\n`:
+		default:
+			if url == "" {
+				url = args[i]
+			}
+		}
+	}
+	if bookmark != "" {
+		url += "#" + bookmark
+	}
+	return url
+}
+
+// fieldArgs splits field instructions into arguments at white space,
+// keeping quoted arguments whole
+func fieldArgs(instr string) []string {
+	var args []string
+	for {
+		instr = strings.TrimLeftFunc(instr, unicode.IsSpace)
+		if instr == "" {
+			return args
+		}
+		if instr[0] == '"' {
+			end := strings.IndexByte(instr[1:], '"')
+			if end < 0 {
+				return append(args, instr[1:])
+			}
+			args = append(args, instr[1:1+end])
+			instr = instr[2+end:]
+			continue
+		}
+		end := strings.IndexFunc(instr, unicode.IsSpace)
+		if end < 0 {
+			return append(args, instr)
+		}
+		args = append(args, instr[:end])
+		instr = instr[end:]
+	}
+}
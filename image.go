@@ -0,0 +1,260 @@
+package doc
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/richardlehane/mscfb"
+)
+
+var (
+	// ErrImageIndex is returned by ExtractImage for an index past the
+	// document's images
+	ErrImageIndex = errors.New("image index out of range")
+)
+
+// ImageInfo describes a picture stored in the Data stream of a .doc file
+type ImageInfo struct {
+	// Format is guessed from the image's first bytes, falling back to the
+	// type of the record holding it: "png", "jpeg", "gif", "bmp", "tiff",
+	// "emf", "wmf", "pict", "dib" or "unknown"
+	Format string
+	Size   int   // size of the image data in bytes
+	Offset int64 // offset of the image data in the Data stream
+}
+
+// ListImages lists the inline pictures of the .doc file in r without
+// decoding them. Documents without pictures give an empty slice.
+func ListImages(r io.Reader) ([]ImageInfo, error) {
+	ra, release, err := readerAt(r, Options{})
+	if err != nil {
+		return nil, wrapError(err)
+	}
+	defer release()
+
+	pd, err := openDoc(ra, Options{})
+	if err != nil {
+		return nil, wrapError(err)
+	}
+	images, err := listImages(pd)
+	if err != nil {
+		return nil, wrapError(err)
+	}
+	return images, nil
+}
+
+// ExtractImage returns the bytes of the image at index in the list given by
+// ListImages, and their MIME type. Metafiles are returned as stored, which
+// may be compressed, and DIBs lack a bitmap file header, so both are
+// "application/octet-stream" unless their format could be recognized.
+func ExtractImage(r io.Reader, index int) ([]byte, string, error) {
+	ra, release, err := readerAt(r, Options{})
+	if err != nil {
+		return nil, "", wrapError(err)
+	}
+	defer release()
+
+	pd, err := openDoc(ra, Options{})
+	if err != nil {
+		return nil, "", wrapError(err)
+	}
+	images, err := listImages(pd)
+	if err != nil {
+		return nil, "", wrapError(err)
+	}
+	if index < 0 || index >= len(images) {
+		return nil, "", fmt.Errorf("%w: %d of %d images", ErrImageIndex, index, len(images))
+	}
+
+	img := images[index]
+	b, err := readTableBytes(pd.data, int(img.Offset), img.Size)
+	if err != nil {
+		return nil, "", wrapError(err)
+	}
+	return b, imageMIMETypes[img.Format], nil
+}
+
+// imageMIMETypes maps ImageInfo formats to MIME types
+var imageMIMETypes = map[string]string{
+	"png":     "image/png",
+	"jpeg":    "image/jpeg",
+	"gif":     "image/gif",
+	"bmp":     "image/bmp",
+	"tiff":    "image/tiff",
+	"emf":     "image/emf",
+	"wmf":     "image/wmf",
+	"pict":    "application/octet-stream",
+	"dib":     "application/octet-stream",
+	"unknown": "application/octet-stream",
+}
+
+// listImages finds the pictures the 0x01 characters of pd point to with
+// sprmCPicLocation, in document order. Word also sets a location, usually
+// 0, on field characters and other special characters, so only 0x01
+// characters with sprmCFSpec count, and pictures whose PICF can't be read
+// are skipped.
+func listImages(pd *parsedDoc) ([]ImageInfo, error) {
+	images := []ImageInfo{}
+	data := pd.data
+	if data == nil {
+		return images, nil
+	}
+	runs, err := getChpxRuns(pd.wordDoc, pd.table, pd.fib)
+	if err != nil {
+		return nil, err
+	}
+
+	seen := map[int]bool{}
+	plc := pd.clx.pcdt.PlcPcd
+	for i, pcd := range plc.aPcd {
+		start, width := pcd.fc.fc, 2
+		if pcd.fc.fCompressed {
+			start, width = pcd.fc.fc/2, 1
+		}
+		end := start + width*(plc.aCP[i+1]-plc.aCP[i])
+		if end < start || int64(end) > pd.wordDoc.Size {
+			continue
+		}
+		for _, run := range splitPiece(runs, start, end, pcd.fc.fCompressed) {
+			loc := run.props.picLocation
+			if !run.props.hasPic || !run.props.spec || seen[loc] {
+				continue
+			}
+			if !hasPictureChar(pd.wordDoc, start+run.from, start+run.to, width) {
+				continue
+			}
+			seen[loc] = true
+			b, offset, err := readPicture(data, loc)
+			if err != nil {
+				continue
+			}
+			images = findBlips(b, offset, images)
+		}
+	}
+	return images, nil
+}
+
+// hasPictureChar reports whether the text at [start, end) in the
+// WordDocument stream, width bytes per character, holds a 0x01 character
+func hasPictureChar(wordDoc *mscfb.File, start, end, width int) bool {
+	b, err := readTableBytes(wordDoc, start, end-start)
+	if err != nil {
+		return false
+	}
+	for i := 0; i+width <= len(b); i += width {
+		if b[i] == 0x01 && (width == 1 || b[i+1] == 0) {
+			return true
+		}
+	}
+	return false
+}
+
+// readPicture reads the PICFAndOfficeArtData at loc in the Data stream
+// (section 2.9.192), returning the OfficeArt records following the PICF
+// and the picture name, if any, and their offset
+func readPicture(data *mscfb.File, loc int) ([]byte, int64, error) {
+	header, err := readTableBytes(data, loc, 6)
+	if err != nil {
+		return nil, 0, err
+	}
+	lcb := int(binary.LittleEndian.Uint32(header))
+	cbHeader := int(binary.LittleEndian.Uint16(header[4:]))
+	if cbHeader < 0x44 || lcb < cbHeader {
+		return nil, 0, errInvalidArgument
+	}
+	b, err := readTableBytes(data, loc, lcb)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	start := cbHeader
+	if binary.LittleEndian.Uint16(b[6:]) == 0x0066 && start < lcb { // MM_SHAPEFILE: name follows
+		start += 1 + int(b[start])
+	}
+	if start > lcb {
+		return nil, 0, errInvalidArgument
+	}
+	return b[start:], int64(loc + start), nil
+}
+
+// blipFormats names the format of each OfficeArt BLIP record type
+// (section 2.2.23 of [MS-ODRAW])
+var blipFormats = map[uint16]string{
+	0xF01A: "emf",
+	0xF01B: "wmf",
+	0xF01C: "pict",
+	0xF01D: "jpeg",
+	0xF01E: "png",
+	0xF01F: "dib",
+	0xF029: "tiff",
+	0xF02A: "jpeg",
+}
+
+// findBlips appends the images in the OfficeArt records of b, which
+// starts at offset base in the Data stream, to images
+func findBlips(b []byte, base int64, images []ImageInfo) []ImageInfo {
+	pos := 0
+	for pos+8 <= len(b) {
+		verInstance := binary.LittleEndian.Uint16(b[pos:])
+		recType := binary.LittleEndian.Uint16(b[pos+2:])
+		recLen := int(binary.LittleEndian.Uint32(b[pos+4:]))
+		body := pos + 8
+		if recLen < 0 || body+recLen > len(b) {
+			break
+		}
+		pos = body + recLen
+
+		switch format, isBlip := blipFormats[recType]; {
+		case verInstance&0xF == 0xF: // container
+			images = findBlips(b[body:pos], base+int64(body), images)
+		case recType == 0xF007: // OfficeArtFBSE, optionally followed by its BLIP
+			if recLen >= 36 {
+				start := body + 36 + int(b[body+33])
+				if start <= pos {
+					images = findBlips(b[start:pos], base+int64(start), images)
+				}
+			}
+		case isBlip:
+			// rgbUid1, rgbUid2 for odd instances, then a 1-byte tag or a
+			// 34-byte metafile header
+			start := body + 16*(1+int(verInstance>>4&1))
+			if recType <= 0xF01C {
+				start += 34
+			} else {
+				start++
+			}
+			if start > pos {
+				break
+			}
+			if f := imageFormat(b[start:pos]); f != "unknown" {
+				format = f
+			}
+			images = append(images, ImageInfo{Format: format, Size: pos - start, Offset: base + int64(start)})
+		}
+	}
+	return images
+}
+
+// imageFormat guesses the format of an image from its first bytes
+func imageFormat(b []byte) string {
+	switch {
+	case bytes.HasPrefix(b, []byte("\x89PNG\r\n\x1a\n")):
+		return "png"
+	case bytes.HasPrefix(b, []byte("\xFF\xD8\xFF")):
+		return "jpeg"
+	case bytes.HasPrefix(b, []byte("GIF87a")), bytes.HasPrefix(b, []byte("GIF89a")):
+		return "gif"
+	case bytes.HasPrefix(b, []byte("BM")):
+		return "bmp"
+	case bytes.HasPrefix(b, []byte("II*\x00")), bytes.HasPrefix(b, []byte("MM\x00*")):
+		return "tiff"
+	case len(b) >= 44 && string(b[40:44]) == " EMF":
+		return "emf"
+	case bytes.HasPrefix(b, []byte("\xD7\xCD\xC6\x9A")):
+		return "wmf"
+	}
+	return "unknown"
+}
@@ -0,0 +1,172 @@
+package doc
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"os"
+	"testing"
+)
+
+func TestListImages(t *testing.T) {
+	png := []byte("\x89PNG\r\n\x1a\nnot really a png")
+	jpeg := []byte("\xFF\xD8\xFF\xE0not really a jpeg")
+	data := picture(0xF01E, 0x6E0, png)
+	jpegLoc := len(data)
+	data = append(data, picture(0xF01D, 0x46B, jpeg)...)
+
+	b := testDoc{
+		pieces: []testPiece{
+			{text: "A picture: ", compressed: true},
+			{text: "\x01", compressed: true, grpprl: picLocation(0)},
+			{text: " and another: ", compressed: true},
+			{text: "\x01", compressed: true, grpprl: picLocation(jpegLoc)},
+			{text: "\r", compressed: true},
+		},
+		streams: []cfbEntry{{name: "Data", data: data}},
+	}.build()
+
+	images, err := ListImages(bytes.NewReader(b))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(images) != 2 {
+		t.Fatalf("expected 2 images, got %+v", images)
+	}
+	for i, want := range []struct {
+		format string
+		img    []byte
+	}{{"png", png}, {"jpeg", jpeg}} {
+		img := images[i]
+		if img.Format != want.format || img.Size != len(want.img) {
+			t.Errorf("image %d: got %+v, want %s of %d bytes", i, img, want.format, len(want.img))
+			continue
+		}
+		if got := data[img.Offset : img.Offset+int64(img.Size)]; !bytes.Equal(got, want.img) {
+			t.Errorf("image %d: offset points to %q", i, got)
+		}
+	}
+}
+
+func TestListImagesSpecialCharacters(t *testing.T) {
+	// Word sets sprmCPicLocation, usually to 0, on field characters too;
+	// only 0x01 characters with sprmCFSpec are pictures, and one whose
+	// PICF can't be read is skipped
+	png := []byte("\x89PNG\r\n\x1a\nnot really a png")
+	data := append(make([]byte, 8), picture(0xF01E, 0x6E0, png)...)
+	locOnly := binary.LittleEndian.AppendUint32([]byte{0x03, 0x6A}, 0)
+	b := testDoc{
+		pieces: []testPiece{
+			{text: "\x13", compressed: true, grpprl: locOnly},
+			{text: " PAGE ", compressed: true},
+			{text: "\x14", compressed: true, grpprl: locOnly},
+			{text: "1", compressed: true},
+			{text: "\x15", compressed: true, grpprl: locOnly},
+			{text: " no spec: ", compressed: true},
+			{text: "\x01", compressed: true, grpprl: locOnly},
+			{text: " bad PICF: ", compressed: true},
+			{text: "\x01", compressed: true, grpprl: picLocation(1)},
+			{text: " picture: ", compressed: true},
+			{text: "\x01", grpprl: picLocation(8)},
+			{text: "\r", compressed: true},
+		},
+		streams: []cfbEntry{{name: "Data", data: data}},
+	}.build()
+
+	images, err := ListImages(bytes.NewReader(b))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(images) != 1 || images[0].Format != "png" || images[0].Size != len(png) {
+		t.Errorf("expected only the png, got %+v", images)
+	}
+}
+
+func TestListImagesRealDocument(t *testing.T) {
+	// docFile.doc has fields, whose characters carry a picture location
+	// of 0, but no pictures
+	f, err := os.Open(`testData/docFile.doc`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	images, err := ListImages(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(images) != 0 {
+		t.Errorf("expected no images, got %+v", images)
+	}
+}
+
+func TestListImagesNone(t *testing.T) {
+	b := testDoc{pieces: []testPiece{{text: "No pictures\r", compressed: true}}}.build()
+	images, err := ListImages(bytes.NewReader(b))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if images == nil || len(images) != 0 {
+		t.Errorf("expected an empty slice, got %#v", images)
+	}
+}
+
+func TestExtractImage(t *testing.T) {
+	png := []byte("\x89PNG\r\n\x1a\nnot really a png")
+	b := testDoc{
+		pieces: []testPiece{
+			{text: "\x01", compressed: true, grpprl: picLocation(0)},
+			{text: "\r", compressed: true},
+		},
+		streams: []cfbEntry{{name: "Data", data: picture(0xF01E, 0x6E0, png)}},
+	}.build()
+
+	img, mimeType, err := ExtractImage(bytes.NewReader(b), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.HasPrefix(img, []byte("\x89PNG")) || !bytes.Equal(img, png) {
+		t.Errorf("got image %q", img)
+	}
+	if mimeType != "image/png" {
+		t.Errorf("got MIME type %q", mimeType)
+	}
+
+	for _, index := range []int{-1, 1} {
+		if _, _, err := ExtractImage(bytes.NewReader(b), index); !errors.Is(err, ErrImageIndex) {
+			t.Errorf("index %d: expected ErrImageIndex, got %v", index, err)
+		}
+	}
+}
+
+func TestExtractImageRealDocument(t *testing.T) {
+	// docFile.doc has fields but no pictures: the index is out of range
+	// rather than the document unreadable
+	f, err := os.Open(`testData/docFile.doc`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if _, _, err := ExtractImage(f, 0); !errors.Is(err, ErrImageIndex) {
+		t.Errorf("expected ErrImageIndex, got %v", err)
+	}
+
+	// a picture after a field, whose characters carry location 0
+	png := []byte("\x89PNG\r\n\x1a\nnot really a png")
+	locOnly := binary.LittleEndian.AppendUint32([]byte{0x03, 0x6A}, 0)
+	b := testDoc{
+		pieces: []testPiece{
+			{text: "\x13", compressed: true, grpprl: locOnly},
+			{text: " PAGE \x141\x15 ", compressed: true},
+			{text: "\x01", compressed: true, grpprl: picLocation(8)},
+			{text: "\r", compressed: true},
+		},
+		streams: []cfbEntry{{name: "Data", data: append(make([]byte, 8), picture(0xF01E, 0x6E0, png)...)}},
+	}.build()
+	img, mimeType, err := ExtractImage(bytes.NewReader(b), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(img, png) || mimeType != "image/png" {
+		t.Errorf("got %q image %q", mimeType, img)
+	}
+}
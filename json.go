@@ -0,0 +1,37 @@
+package doc
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+)
+
+// JSONSchemaVersion is the value of the top-level "version" field written by
+// ParseDocJSON. It changes whenever the schema changes incompatibly.
+const JSONSchemaVersion = 1
+
+// ParseDocJSON parses the .doc file in r and serializes its Document
+// (metadata, paragraphs and runs) as JSON for non-Go tooling
+func ParseDocJSON(r io.Reader) ([]byte, error) {
+	d, err := ParseDocument(r)
+	if err != nil {
+		return nil, err
+	}
+	return marshalDocument(d)
+}
+
+func marshalDocument(d *Document) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	// encoding/json replaces any invalid UTF-8 with U+FFFD
+	err := enc.Encode(struct {
+		Version int `json:"version"`
+		*Document
+	}{JSONSchemaVersion, d})
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,293 @@
+package doc
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+
+	"github.com/richardlehane/mscfb"
+)
+
+var errInvalidPlfLst = errors.New("invalid PlfLst structure")
+
+// listCounts holds how many items each level of each list has numbered
+// since it last restarted
+type listCounts map[string]*[9]int
+
+// listLevel is the numbering of a level of a list defined by the document,
+// from its LVL
+type listLevel struct {
+	start int    // number of the level's first item
+	nfc   byte   // number format, an MSONFC
+	legal bool   // show the numbers of higher levels in Arabic numerals
+	text  string // number text, where characters 0-8 stand for those levels' numbers
+}
+
+// listDef holds the levels of a list defined by the document: nine, or one
+// for simple lists
+type listDef []listLevel
+
+// listDefs holds the lists a document names, by lowercase name
+type listDefs map[string]listDef
+
+// listFormats gives the number format of each level of Word's built-in
+// lists, which the AUTONUM fields and LISTNUM fields naming no list of the
+// document number from. "%" stands for the number: "1" in Arabic numerals,
+// "a" and "A" in letters, "i" and "I" in Roman numerals. Legal lists show
+// the numbers of all levels instead.
+var listFormats = map[string][9]string{
+	"numberdefault":  {"1)", "a)", "i)", "(1)", "(a)", "(i)", "1.", "a.", "i."},
+	"outlinedefault": {"I.", "A.", "1.", "a)", "(1)", "(a)", "(i)", "(a)", "(i)"},
+	"autonum":        {"1.", "a.", "i.", "1.", "a.", "i.", "1.", "a.", "i."},
+}
+
+// listNumber advances the numbering of an auto-numbering field (LISTNUM,
+// AUTONUM, AUTONUMLGL or AUTONUMOUT) with instructions instr and returns
+// its number as Word shows it. ok is false for other fields.
+func (w *textWriter) listNumber(instr string) (num string, ok bool) {
+	args := fieldArgs(instr)
+	if len(args) == 0 {
+		return "", false
+	}
+	list, level, start := "", 1, 0
+	switch strings.ToUpper(args[0]) {
+	case "LISTNUM":
+		list = "numberdefault"
+		for i := 1; i < len(args); i++ {
+			switch strings.ToLower(args[i]) {
+			case `\l`, `\s`: // level and start number
+				if i+1 < len(args) {
+					n, err := strconv.Atoi(args[i+1])
+					if err == nil && args[i] == `\l` {
+						level = min(max(n, 1), 9)
+					} else if err == nil {
+						start = max(n, 1)
+					}
+					i++
+				}
+			default:
+				if i == 1 { // the list's name
+					list = strings.ToLower(args[i])
+				}
+			}
+		}
+	case "AUTONUM":
+		list = "autonum"
+	case "AUTONUMLGL":
+		list = "legaldefault"
+	case "AUTONUMOUT":
+		list = "outlinedefault"
+	default:
+		return "", false
+	}
+
+	if w.lists == nil {
+		w.lists = listCounts{}
+	}
+	counts := w.lists[list]
+	if counts == nil {
+		counts = &[9]int{}
+		w.lists[list] = counts
+	}
+	def := w.docLists[list]
+	if def != nil { // simple lists number all levels as their only one
+		level = min(level, len(def))
+	}
+	counts[level-1]++
+	if start > 0 {
+		counts[level-1] = start - def.first(level-1) + 1
+	}
+	clear(counts[level:])
+
+	if def != nil {
+		return def.format(level-1, counts), true
+	}
+
+	if list == "legaldefault" {
+		var sb strings.Builder
+		for _, n := range counts[:level] {
+			sb.WriteString(strconv.Itoa(max(n, 1)))
+			sb.WriteByte('.')
+		}
+		return sb.String(), true
+	}
+	formats, known := listFormats[list]
+	if !known { // lists missing from the document number like NumberDefault
+		formats = listFormats["numberdefault"]
+	}
+	return formatListNumber(formats[level-1], counts[level-1]), true
+}
+
+// level returns level i of d, or the only level of a simple list
+func (d listDef) level(i int) listLevel {
+	return d[min(i, len(d)-1)]
+}
+
+// first returns the number of the first item of level i, which is 1 for
+// Word's built-in lists
+func (d listDef) first(i int) int {
+	if d == nil {
+		return 1
+	}
+	return d.level(i).start
+}
+
+// format writes the number of level i as the list shows it, given the
+// counts its levels have reached
+func (d listDef) format(i int, counts *[9]int) string {
+	lvl := d.level(i)
+	var sb strings.Builder
+	for _, r := range lvl.text {
+		if r > 8 {
+			sb.WriteRune(r)
+			continue
+		}
+		k := int(r)
+		nfc := d.level(k).nfc
+		if lvl.legal && k < i && nfc != nfcDecimalZero {
+			nfc = nfcDecimal
+		}
+		sb.WriteString(formatNumber(nfc, d.first(k)+max(counts[k], 1)-1))
+	}
+	return sb.String()
+}
+
+// number formats (MSONFC) of list levels
+const (
+	nfcDecimal     = 0
+	nfcUpperRoman  = 1
+	nfcLowerRoman  = 2
+	nfcUpperLetter = 3
+	nfcLowerLetter = 4
+	nfcDecimalZero = 22
+	nfcNone        = 255
+)
+
+// formatListNumber writes n in a format of listFormats
+func formatListNumber(format string, n int) string {
+	i := strings.IndexAny(format, "1aAiI")
+	nfc := byte(strings.IndexByte("1IiAa", format[i])) // in MSONFC order
+	return format[:i] + formatNumber(nfc, n) + format[i+1:]
+}
+
+// formatNumber writes n in number format nfc. Formats other than Arabic
+// numerals, letters and Roman numerals are written as Arabic numerals.
+func formatNumber(nfc byte, n int) string {
+	switch nfc {
+	case nfcUpperRoman:
+		return strings.ToUpper(romanNumber(n))
+	case nfcLowerRoman:
+		return romanNumber(n)
+	case nfcUpperLetter:
+		return strings.ToUpper(letterNumber(n))
+	case nfcLowerLetter:
+		return letterNumber(n)
+	case nfcDecimalZero:
+		if n >= 0 && n < 10 {
+			return "0" + strconv.Itoa(n)
+		}
+	case nfcNone:
+		return ""
+	}
+	return strconv.Itoa(n)
+}
+
+// letterNumber writes n as Word's lettered lists do: a to z, then aa to
+// zz and so on
+func letterNumber(n int) string {
+	return strings.Repeat(string(rune('a'+(n-1)%26)), (n-1)/26+1)
+}
+
+// romanNumber writes n in lowercase Roman numerals
+func romanNumber(n int) string {
+	var sb strings.Builder
+	for _, d := range []struct {
+		value  int
+		digits string
+	}{
+		{1000, "m"}, {900, "cm"}, {500, "d"}, {400, "cd"}, {100, "c"}, {90, "xc"},
+		{50, "l"}, {40, "xl"}, {10, "x"}, {9, "ix"}, {5, "v"}, {4, "iv"}, {1, "i"},
+	} {
+		for ; n >= d.value; n -= d.value {
+			sb.WriteString(d.digits)
+		}
+	}
+	return sb.String()
+}
+
+// getListDefs reads the lists the document names, which LISTNUM fields
+// number from: their names from SttbListNames, and their levels from the
+// LSTFs of PlfLst and the LVLs that follow it in the table stream
+func getListDefs(table *mscfb.File, fib *fib) (listDefs, error) {
+	rg := fib.fibRgFcLcb
+	b, err := readTableBytes(table, rg.fcSttbListNames, rg.lcbSttbListNames)
+	if err != nil || b == nil {
+		return nil, err
+	}
+	names, _, err := parseSttb(b)
+	if err != nil {
+		return nil, err
+	}
+
+	b, err = readTableBytes(table, rg.fcPlfLst, rg.lcbPlfLst)
+	if err != nil || b == nil {
+		return nil, err
+	}
+	if len(b) < 2 {
+		return nil, errInvalidPlfLst
+	}
+	cLst := int(int16(binary.LittleEndian.Uint16(b)))
+	if cLst < 0 || len(b) < 2+cLst*28 { // an LSTF is 28 bytes
+		return nil, errInvalidPlfLst
+	}
+	fcLvls := int64(rg.fcPlfLst + rg.lcbPlfLst)
+	lvls := bufio.NewReader(io.NewSectionReader(table, fcLvls, table.Size-fcLvls))
+	defs := listDefs{}
+	for i := 0; i < min(cLst, len(names)); i++ {
+		def := make(listDef, 9)
+		if b[2+i*28+26]&0x01 != 0 { // fSimpleList
+			def = def[:1]
+		}
+		for j := range def {
+			if def[j], err = readLvl(lvls); err != nil {
+				return nil, err
+			}
+		}
+		if names[i] != "" {
+			defs[strings.ToLower(names[i])] = def
+		}
+	}
+	return defs, nil
+}
+
+// readLvl reads the number format and text of an LVL, skipping the
+// paragraph and character properties of its number
+func readLvl(r io.Reader) (listLevel, error) {
+	var lvlf [28]byte
+	if _, err := io.ReadFull(r, lvlf[:]); err != nil {
+		return listLevel{}, errInvalidPlfLst
+	}
+	lvl := listLevel{
+		start: int(int32(binary.LittleEndian.Uint32(lvlf[:]))),
+		nfc:   lvlf[4],
+		legal: lvlf[5]&0x04 != 0,
+	}
+	// grpprlPapx and grpprlChpx
+	if _, err := io.CopyN(io.Discard, r, int64(lvlf[24])+int64(lvlf[25])); err != nil {
+		return listLevel{}, errInvalidPlfLst
+	}
+	var cch uint16
+	if err := binary.Read(r, binary.LittleEndian, &cch); err != nil {
+		return listLevel{}, errInvalidPlfLst
+	}
+	units := make([]uint16, cch)
+	if err := binary.Read(r, binary.LittleEndian, units); err != nil {
+		return listLevel{}, errInvalidPlfLst
+	}
+	lvl.text = string(utf16.Decode(units))
+	return lvl, nil
+}
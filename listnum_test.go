@@ -0,0 +1,77 @@
+package doc
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestParseListNumFields(t *testing.T) {
+	b := testDoc{pieces: []testPiece{
+		// LISTNUM fields saved without cached results
+		{text: "\x13 LISTNUM \x15 Tea\r\x13 LISTNUM \\l 2 \x15 Green\r\x13 LISTNUM \\l 2 \x14\x15 Black\r", compressed: true},
+		{text: "\x13 LISTNUM \x15 Coffee\r\x13 LISTNUM NumberDefault \\l 3 \x15 Arabica\r", compressed: true},
+		// a cached result is kept and still counts
+		{text: "\x13 LISTNUM \x14(3)\x15 Water\r", compressed: true},
+		{text: "\x13 AUTONUMLGL \x15 Scope \x13 AUTONUMOUT \x15 Terms\r", compressed: true},
+		{text: "\x13 LISTNUM \\s 9 \x15 Juice\r", compressed: true},
+		// a LISTNUM nested in another field's instructions isn't shown
+		{text: "\x13 IF \x13 LISTNUM \x15 = 1 \"yes\" \x14yes\x15\r", compressed: true},
+	}}.build()
+
+	buf, err := ParseDoc(bytes.NewReader(b))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "1) Tea\ra) Green\rb) Black\r2) Coffee\ri) Arabica\r(3) Water\r1. Scope I. Terms\r9) Juice\ryes\r"
+	if s := buf.(*bytes.Buffer).String(); s != expected {
+		t.Errorf("expected %q, got %q", expected, s)
+	}
+}
+
+func TestParseListNumDocumentLists(t *testing.T) {
+	b := testDoc{
+		pieces: []testPiece{
+			{text: "\x13 LISTNUM Steps \x15 Scope\r\x13 LISTNUM Steps \\l 2 \x15 Terms\r\x13 LISTNUM Steps \\l 2 \x15 Fees\r", compressed: true},
+			{text: "\x13 LISTNUM steps \x15 Law\r\x13 LISTNUM Steps \\l 2 \x15 Courts\r\x13 LISTNUM Steps \\s 7 \x15 Notices\r", compressed: true},
+			// a simple list has one level, and can start at 0
+			{text: "\x13 LISTNUM Codes \x15 Red\r\x13 LISTNUM Codes \\l 2 \x15 Blue\r", compressed: true},
+			// lists the document doesn't name are formatted like NumberDefault
+			{text: "\x13 LISTNUM Other \x15 Tea\r\x13 LISTNUM \x15 Juice\r", compressed: true},
+		},
+		lists: listDefs{
+			"Steps": {
+				{start: 3, nfc: nfcUpperRoman, text: "Article \x00"},
+				{start: 1, nfc: nfcLowerLetter, legal: true, text: "\x00.\x01"},
+			},
+			"Codes": {{start: 0, nfc: nfcDecimalZero, text: "\x00 -"}},
+		},
+	}.build()
+
+	buf, err := ParseDoc(bytes.NewReader(b))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "Article III Scope\r3.a Terms\r3.b Fees\rArticle IV Law\r4.a Courts\rArticle VII Notices\r" +
+		"00 - Red\r01 - Blue\r1) Tea\r1) Juice\r"
+	if s := buf.(*bytes.Buffer).String(); s != expected {
+		t.Errorf("expected %q, got %q", expected, s)
+	}
+}
+
+func TestFormatListNumber(t *testing.T) {
+	for _, test := range []struct {
+		format   string
+		n        int
+		expected string
+	}{
+		{"1)", 12, "12)"},
+		{"(a)", 28, "(bb)"},
+		{"A.", 3, "C."},
+		{"i.", 14, "xiv."},
+		{"I.", 1994, "MCMXCIV."},
+	} {
+		if s := formatListNumber(test.format, test.n); s != test.expected {
+			t.Errorf("%q %d: expected %q, got %q", test.format, test.n, test.expected, s)
+		}
+	}
+}
@@ -0,0 +1,260 @@
+package doc
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+	"strconv"
+	"time"
+	"unicode/utf16"
+
+	"github.com/richardlehane/mscfb"
+)
+
+var (
+	errPropertySet = errors.New("invalid property set stream")
+)
+
+// Metadata holds the document properties stored in the SummaryInformation
+// stream ([MS-OLEPS] section 2.21, [MS-OSHARED] section 2.3.3.2.1)
+type Metadata struct {
+	Title    string `json:"title,omitempty"`
+	Subject  string `json:"subject,omitempty"`
+	Author   string `json:"author,omitempty"`
+	Keywords string `json:"keywords,omitempty"`
+	Comments string `json:"comments,omitempty"`
+
+	LastSavedBy string `json:"lastSavedBy,omitempty"`
+	// Revision is usually a number, but is stored as a string
+	Revision string `json:"revision,omitempty"`
+	// EditTime is the total time spent editing the document
+	EditTime time.Duration `json:"editTime,omitempty"`
+}
+
+// property identifiers in the SummaryInformation property set
+const (
+	pidCodepage   = 0x01
+	pidTitle      = 0x02
+	pidSubject    = 0x03
+	pidAuthor     = 0x04
+	pidKeywords   = 0x05
+	pidComments   = 0x06
+	pidLastAuthor = 0x08
+	pidRevNumber  = 0x09
+	pidEditTime   = 0x0A
+)
+
+// property types (section 2.15)
+const (
+	vtI2       = 0x0002
+	vtI4       = 0x0003
+	vtLPSTR    = 0x001E
+	vtLPWSTR   = 0x001F
+	vtFILETIME = 0x0040
+)
+
+// ReadMetadata returns the document properties of the .doc file in r.
+// A document without a SummaryInformation stream yields empty Metadata.
+func ReadMetadata(r io.Reader) (*Metadata, error) {
+	ra, release, err := readerAt(r, Options{})
+	if err != nil {
+		return nil, wrapError(err)
+	}
+	defer release()
+
+	d, err := newCFB(ra)
+	if err != nil {
+		return nil, wrapError(err)
+	}
+	m, err := getMetadata(d)
+	if err != nil {
+		return nil, wrapError(err)
+	}
+	return m, nil
+}
+
+func getMetadata(d *mscfb.Reader) (*Metadata, error) {
+	stream := findStream(d, "SummaryInformation")
+	if stream == nil {
+		return &Metadata{}, nil
+	}
+	b := make([]byte, stream.Size)
+	if _, err := stream.ReadAt(b, 0); err != nil {
+		return nil, err
+	}
+	props, err := parsePropertySet(b)
+	if err != nil {
+		return nil, err
+	}
+	return &Metadata{
+		Title:    props.str(pidTitle),
+		Subject:  props.str(pidSubject),
+		Author:   props.str(pidAuthor),
+		Keywords: props.str(pidKeywords),
+		Comments: props.str(pidComments),
+
+		LastSavedBy: props.str(pidLastAuthor),
+		Revision:    props.revision(),
+		EditTime:    props.duration(pidEditTime),
+	}, nil
+}
+
+// findStream returns the stream called name at the root of the compound
+// file, or nil. Leading control characters (such as the 0x05 prefixing
+// property set streams) are already stripped from names by mscfb.
+func findStream(d *mscfb.Reader, name string) *mscfb.File {
+	for _, f := range d.File {
+		if f.Name == name && len(f.Path) == 0 {
+			return f
+		}
+	}
+	return nil
+}
+
+// properties maps property identifiers to decoded values (string, int32
+// or uint64 for FILETIME)
+type properties map[uint32]interface{}
+
+func (p properties) str(id uint32) string {
+	s, _ := p[id].(string)
+	return s
+}
+
+// revision returns the revision number, which some writers store as VT_I4
+// rather than the VT_LPSTR the specification calls for
+func (p properties) revision() string {
+	if n, ok := p[pidRevNumber].(int32); ok {
+		return strconv.Itoa(int(n))
+	}
+	return p.str(pidRevNumber)
+}
+
+// duration reads a FILETIME holding a time span in 100-nanosecond intervals
+func (p properties) duration(id uint32) time.Duration {
+	ft, _ := p[id].(uint64)
+	return time.Duration(ft) * 100
+}
+
+// parse the first property set of a PropertySetStream (section 2.21)
+func parsePropertySet(b []byte) (properties, error) {
+	if len(b) < 48 || binary.LittleEndian.Uint16(b) != 0xFFFE {
+		return nil, errPropertySet
+	}
+	offset := int(binary.LittleEndian.Uint32(b[44:48])) // skip header and FMTID0
+	if offset < 0 || offset+8 > len(b) {
+		return nil, errPropertySet
+	}
+	set := b[offset:]
+	numProps := int(binary.LittleEndian.Uint32(set[4:8]))
+	if numProps < 0 || 8+numProps*8 > len(set) {
+		return nil, errPropertySet
+	}
+
+	// the code page governs how VT_LPSTR values are decoded, so find it first
+	codepage := 1252
+	for i := 0; i < numProps; i++ {
+		id := binary.LittleEndian.Uint32(set[8+i*8:])
+		off := int(binary.LittleEndian.Uint32(set[12+i*8:]))
+		if id == pidCodepage && off >= 0 && off+6 <= len(set) && binary.LittleEndian.Uint16(set[off:]) == vtI2 {
+			codepage = int(binary.LittleEndian.Uint16(set[off+4:]))
+		}
+	}
+
+	props := properties{}
+	for i := 0; i < numProps; i++ {
+		id := binary.LittleEndian.Uint32(set[8+i*8:])
+		off := int(binary.LittleEndian.Uint32(set[12+i*8:]))
+		if off < 0 || off+4 > len(set) {
+			return nil, errPropertySet
+		}
+		if v, ok := parseProperty(set[off:], codepage); ok {
+			props[id] = v
+		}
+	}
+	return props, nil
+}
+
+// parse a TypedPropertyValue (section 2.15), skipping unsupported types
+func parseProperty(b []byte, codepage int) (interface{}, bool) {
+	typ := binary.LittleEndian.Uint16(b)
+	val := b[4:]
+	switch typ {
+	case vtI2:
+		if len(val) < 2 {
+			return nil, false
+		}
+		return int32(int16(binary.LittleEndian.Uint16(val))), true
+	case vtI4:
+		if len(val) < 4 {
+			return nil, false
+		}
+		return int32(binary.LittleEndian.Uint32(val)), true
+	case vtFILETIME:
+		if len(val) < 8 {
+			return nil, false
+		}
+		return binary.LittleEndian.Uint64(val), true
+	case vtLPSTR:
+		if len(val) < 4 {
+			return nil, false
+		}
+		size := int(binary.LittleEndian.Uint32(val))
+		if size < 0 || 4+size > len(val) {
+			return nil, false
+		}
+		return decodeLPSTR(val[4:4+size], codepage), true
+	case vtLPWSTR:
+		if len(val) < 4 {
+			return nil, false
+		}
+		n := int(binary.LittleEndian.Uint32(val))
+		if n < 0 || 4+n*2 > len(val) {
+			return nil, false
+		}
+		units := make([]uint16, 0, n)
+		for i := 0; i < n; i++ {
+			u := binary.LittleEndian.Uint16(val[4+i*2:])
+			if u == 0 {
+				break
+			}
+			units = append(units, u)
+		}
+		return string(utf16.Decode(units)), true
+	}
+	return nil, false
+}
+
+// decodeLPSTR decodes a null-terminated CodePageString
+func decodeLPSTR(b []byte, codepage int) string {
+	if codepage == 1200 { // UTF-16LE, per section 2.5
+		units := make([]uint16, 0, len(b)/2)
+		for i := 0; i+1 < len(b); i += 2 {
+			u := binary.LittleEndian.Uint16(b[i:])
+			if u == 0 {
+				break
+			}
+			units = append(units, u)
+		}
+		return string(utf16.Decode(units))
+	}
+
+	for i, c := range b {
+		if c == 0 {
+			b = b[:i]
+			break
+		}
+	}
+	if codepage == 65001 { // UTF-8
+		return string(b)
+	}
+	if encodingForCodepage(codepage) == nil {
+		codepage = 1252
+	}
+	dec := getDecoder(codepage)
+	s, err := dec.Bytes(b)
+	putDecoder(codepage, dec)
+	if err != nil {
+		return string(b)
+	}
+	return string(s)
+}
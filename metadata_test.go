@@ -0,0 +1,38 @@
+package doc
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+func TestReadMetadata(t *testing.T) {
+	pieces := []testPiece{{text: "text\r", compressed: true}}
+	b := testDoc{pieces: pieces, streams: []cfbEntry{summaryInformation(map[uint32]interface{}{
+		pidTitle:      "Audit",
+		pidLastAuthor: "John Smith",
+		pidRevNumber:  "14",
+		pidEditTime:   uint64(90 * time.Minute / 100),
+	})}}.build()
+
+	m, err := ReadMetadata(bytes.NewReader(b))
+	if err != nil {
+		t.Fatal("expected successful read", err)
+	}
+	expected := Metadata{Title: "Audit", LastSavedBy: "John Smith", Revision: "14", EditTime: 90 * time.Minute}
+	if *m != expected {
+		t.Errorf("expected %+v, got %+v", expected, *m)
+	}
+
+	// some writers store the revision number as VT_I4
+	b = testDoc{pieces: pieces, streams: []cfbEntry{summaryInformation(map[uint32]interface{}{
+		pidRevNumber: int32(3),
+	})}}.build()
+	m, err = ReadMetadata(bytes.NewReader(b))
+	if err != nil {
+		t.Fatal("expected successful read", err)
+	}
+	if m.Revision != "3" {
+		t.Errorf("expected revision 3, got %q", m.Revision)
+	}
+}
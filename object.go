@@ -0,0 +1,84 @@
+package doc
+
+import (
+	"encoding/binary"
+	"strconv"
+	"strings"
+
+	"github.com/richardlehane/mscfb"
+)
+
+// getObjectProgIDs reads the ProgID, or failing that the user type name, of
+// each OLE object in the ObjectPool of the document in the storage at path,
+// keyed by the number in the name of the object's storage ("_" followed by
+// the sprmCPicLocation of its 0x01 character)
+func getObjectProgIDs(d *mscfb.Reader, path []string) map[int]string {
+	pool := append(append([]string{}, path...), "ObjectPool")
+	names := map[int]string{}
+	for _, f := range d.File {
+		// mscfb drops the leading control character of "\x01CompObj"
+		if f.Name != "CompObj" || len(f.Path) != len(pool)+1 || !samePath(f.Path[:len(pool)], pool) {
+			continue
+		}
+		id, err := strconv.Atoi(strings.TrimPrefix(f.Path[len(pool)], "_"))
+		if err != nil || f.Size > 4096 {
+			continue
+		}
+		b := make([]byte, f.Size)
+		if _, err := f.ReadAt(b, 0); err != nil {
+			continue
+		}
+		if name := parseCompObj(b); name != "" {
+			names[id] = name
+		}
+	}
+	return names
+}
+
+// parseCompObj returns the ProgID in a CompObjStream ([MS-OLEDS] section
+// 2.3.8), or the user type name if the stream has no ProgID
+func parseCompObj(b []byte) string {
+	if len(b) < 28 {
+		return ""
+	}
+	b = b[28:] // CompObjHeader
+	userType, b := lengthPrefixedString(b)
+	if len(b) < 4 {
+		return userType
+	}
+	// AnsiClipboardFormat: a standard format number or a string
+	switch n := binary.LittleEndian.Uint32(b); n {
+	case 0:
+		b = b[4:]
+	case 0xFFFFFFFF, 0xFFFFFFFE:
+		b = b[min(8, len(b)):]
+	default:
+		_, b = lengthPrefixedString(b)
+	}
+	if progID, _ := lengthPrefixedString(b); progID != "" {
+		return progID
+	}
+	return userType
+}
+
+// lengthPrefixedString reads a LengthPrefixedAnsiString ([MS-OLEDS]
+// section 2.1.4) from the start of b, returning it without its terminating
+// NUL and the bytes that follow. A string running past b gives "" and nil.
+func lengthPrefixedString(b []byte) (string, []byte) {
+	if len(b) < 4 {
+		return "", nil
+	}
+	n := binary.LittleEndian.Uint32(b)
+	if n > uint32(len(b)-4) {
+		return "", nil
+	}
+	return decodeLPSTR(b[4:4+n], 1252), b[4+n:]
+}
+
+// objectName returns the name ObjectMarker gives the object at loc
+func (w *textWriter) objectName(loc int) string {
+	if name, ok := w.objects[loc]; ok {
+		return name
+	}
+	return "object"
+}
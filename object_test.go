@@ -0,0 +1,69 @@
+package doc
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+)
+
+// compObj builds a CompObjStream ([MS-OLEDS] section 2.3.8) with a
+// registered clipboard format, or none if format is empty
+func compObj(userType, format, progID string) []byte {
+	b := make([]byte, 28)
+	appendString := func(s string) {
+		b = binary.LittleEndian.AppendUint32(b, uint32(len(s)+1))
+		b = append(append(b, s...), 0)
+	}
+	appendString(userType)
+	if format == "" {
+		b = binary.LittleEndian.AppendUint32(b, 0)
+	} else {
+		appendString(format)
+	}
+	appendString(progID)
+	return append(b, make([]byte, 12)...) // UnicodeMarker and the rest
+}
+
+func TestParseObjectMarker(t *testing.T) {
+	// EMBED fields whose results are 0x01 characters marked as OLE objects,
+	// each naming its storage in the ObjectPool
+	object := func(loc uint32) []byte {
+		return binary.LittleEndian.AppendUint32([]byte{0x0A, 0x08, 0x01, 0x03, 0x6A}, loc)
+	}
+	b := testDoc{
+		pieces: []testPiece{
+			{text: "Sales: \x13 EMBED Excel.Sheet.8 \x14", compressed: true},
+			{text: "\x01", compressed: true, grpprl: object(1234)},
+			{text: "\x15, chart: \x13 EMBED MSGraph.Chart.8 \x14", compressed: true},
+			{text: "\x01", compressed: true, grpprl: object(5678)},
+			{text: "\x15, unknown: \x13 EMBED Package \x14", compressed: true},
+			{text: "\x01", compressed: true, grpprl: object(42)},
+			{text: "\x15\r", compressed: true},
+		},
+		streams: []cfbEntry{{name: "ObjectPool", storage: true, children: []cfbEntry{
+			{name: "_1234", storage: true, children: []cfbEntry{
+				{name: "\x01CompObj", data: compObj("Microsoft Excel Worksheet", "Biff8", "Excel.Sheet.8")},
+			}},
+			{name: "_5678", storage: true, children: []cfbEntry{
+				{name: "\x01CompObj", data: compObj("Microsoft Graph Chart", "", "")},
+			}},
+		}}},
+	}.build()
+
+	buf, err := ParseDoc(bytes.NewReader(b))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := buf.(*bytes.Buffer).String(); s != "Sales: , chart: , unknown: \r" {
+		t.Errorf("expected objects to be dropped by default, got %q", s)
+	}
+
+	buf, err = ParseDocWithOptions(bytes.NewReader(b), Options{ObjectMarker: "[%s]"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "Sales: [Excel.Sheet.8], chart: [Microsoft Graph Chart], unknown: [object]\r"
+	if s := buf.(*bytes.Buffer).String(); s != expected {
+		t.Errorf("expected %q, got %q", expected, s)
+	}
+}
@@ -0,0 +1,30 @@
+package doc
+
+import "io"
+
+// ParseWithOffsets extracts the text of the .doc file in r like ParseDoc,
+// along with the CP (character position in the document) of each rune of
+// the text, for mapping matches back to the document. Text written for a
+// single character, such as the space for a cell mark, maps to that
+// character's CP. Field instructions are not extracted, so the CPs jump
+// over them to the field's result.
+func ParseWithOffsets(r io.Reader) (string, []int, error) {
+	ra, release, err := readerAt(r, Options{})
+	if err != nil {
+		return "", nil, wrapError(err)
+	}
+	defer release()
+
+	pd, err := openDoc(ra, Options{})
+	if err != nil {
+		return "", nil, wrapError(err)
+	}
+
+	w := newTextWriter(Options{})
+	w.trackOffsets = true
+	w.offsets = []int{}
+	if err := writeText(pd, w); err != nil {
+		return "", nil, wrapError(err)
+	}
+	return w.buf.String(), w.offsets, nil
+}
@@ -0,0 +1,40 @@
+package doc
+
+import (
+	"bytes"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestParseWithOffsets(t *testing.T) {
+	b := testDoc{pieces: []testPiece{
+		{text: "Hi \x13 PAGE \x141\x15 there\r", compressed: true},
+		{text: "Ünïcode 😀!\r"},
+	}}.build()
+
+	text, offsets, err := ParseWithOffsets(bytes.NewReader(b))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if text != "Hi 1 there\rÜnïcode 😀!\r" {
+		t.Fatalf("got %q", text)
+	}
+	if n := utf8.RuneCountInString(text); len(offsets) != n {
+		t.Fatalf("got %d offsets for %d runes", len(offsets), n)
+	}
+
+	// rune index -> CP
+	for i, cp := range map[int]int{
+		0:  0,  // H
+		3:  11, // the field result, after "Hi \x13 PAGE \x14"
+		4:  13, // the space after the field end
+		10: 19, // paragraph mark
+		11: 20, // Ü, starting the second piece
+		19: 28, // the emoji, stored as a surrogate pair
+		20: 30, // !
+	} {
+		if offsets[i] != cp {
+			t.Errorf("rune %d: got CP %d, want %d", i, offsets[i], cp)
+		}
+	}
+}
@@ -0,0 +1,48 @@
+package doc
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+)
+
+var (
+	// ErrRTF is returned by Open for Rich Text Format files, which Word
+	// also saves with a .doc extension
+	ErrRTF = errors.New("file is RTF, not a Word binary document")
+)
+
+var (
+	oleSignature = []byte{0xD0, 0xCF, 0x11, 0xE0, 0xA1, 0xB1, 0x1A, 0xE1}
+	zipSignature = []byte("PK\x03\x04")
+	rtfSignature = []byte(`{\rtf`)
+)
+
+// Open parses the .doc file at path like ParseDoc, after checking what
+// kind of file it is. A .docx (ZIP) file yields an error wrapping
+// ErrNotOLE2, an RTF file ErrRTF.
+func Open(path string) (io.Reader, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	header := make([]byte, 8)
+	n, err := io.ReadFull(f, header)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return nil, wrapError(err)
+	}
+	header = header[:n]
+	switch {
+	case bytes.HasPrefix(header, zipSignature):
+		return nil, fmt.Errorf("%w: ZIP archive, probably a .docx", ErrNotOLE2)
+	case bytes.HasPrefix(header, rtfSignature):
+		return nil, ErrRTF
+	case !bytes.Equal(header, oleSignature):
+		return nil, ErrNotOLE2
+	}
+	return ParseDoc(f)
+}
@@ -0,0 +1,42 @@
+package doc
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestOpen(t *testing.T) {
+	buf, err := Open(`testData/simpleDoc.doc`)
+	if err != nil {
+		t.Fatal("expected successful parse", err)
+	}
+	if s := buf.(*bytes.Buffer).String(); s != "12345\r" {
+		t.Errorf("expected correct value |%s|", s)
+	}
+
+	dir := t.TempDir()
+	for name, test := range map[string]struct {
+		data     string
+		expected error
+	}{
+		"report.docx": {"PK\x03\x04\x14\x00\x06\x00", ErrNotOLE2},
+		"letter.doc":  {`{\rtf1\ansi\deff0 {\fonttbl}}`, ErrRTF},
+		"notes.doc":   {"plain text", ErrNotOLE2},
+		"empty.doc":   {"", ErrNotOLE2},
+	} {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(test.data), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := Open(path); !errors.Is(err, test.expected) {
+			t.Errorf("%s: expected %v, got %v", name, test.expected, err)
+		}
+	}
+
+	if _, err := Open(filepath.Join(dir, "missing.doc")); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("expected os.ErrNotExist, got %v", err)
+	}
+}
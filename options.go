@@ -0,0 +1,196 @@
+package doc
+
+import (
+	"time"
+
+	"golang.org/x/text/encoding"
+)
+
+// Options controls how ParseDocWithOptions reads and translates a document.
+// The zero value matches the behaviour of ParseDoc.
+type Options struct {
+	// ReadTimeout bounds how long reading a non-io.ReaderAt input into memory
+	// may take. A reader that stalls for longer yields ErrReadTimeout.
+	// Zero means no timeout.
+	ReadTimeout time.Duration
+
+	// MaxChars stops extraction once this many characters (runes) have been
+	// written, without reading any further pieces. Text suppressed inside
+	// field instructions does not count. Zero means no limit.
+	MaxChars int
+
+	// SectionBreak is written in place of each section mark so multi-section
+	// documents can be split downstream. Page breaks, which use the same
+	// control character, are not affected. Empty means section marks are
+	// dropped like other control characters.
+	SectionBreak string
+
+	// ColumnBreak is written in place of each column break (0x0E) for
+	// reconstructing multi-column layouts. Empty means column breaks are
+	// dropped.
+	ColumnBreak string
+
+	// FootnoteMarker is written in place of each auto-numbered footnote
+	// reference mark, with any "%d" replaced by the reference's number.
+	// Endnote references use the same mark and share the numbering.
+	// Empty means reference marks are dropped, like annotation references
+	// always are.
+	FootnoteMarker string
+
+	// CustomDecoder, when set, decodes every high (0x80 and above) byte of
+	// compressed text instead of the built-in code page handling, for
+	// documents in code pages the package doesn't bundle. Lead bytes of
+	// double-byte code pages are held back until their trail byte arrives,
+	// even across pieces. The decoder is Reset before use, so it must not
+	// be shared between concurrent parses.
+	CustomDecoder *encoding.Decoder
+
+	// Codepage is the Windows code page, such as 1251 for Cyrillic, of
+	// compressed text. Its bytes from 0x80 up, including 0x80-0x9F, are
+	// then decoded in that code page instead of CP1252. Zero uses the code
+	// page of the document's language when the FIB says compressed text
+	// isn't CP1252 (fExtChar clear), else CP1252. Code pages the package
+	// doesn't bundle are ignored; CustomDecoder takes precedence.
+	Codepage int
+
+	// ReplaceInvalid writes U+FFFD for 16-bit units that aren't valid
+	// Unicode (surrogates without their other half) so corruption stays
+	// visible and text lengths stay meaningful. By default they are dropped.
+	// Well-formed surrogate pairs are always decoded.
+	ReplaceInvalid bool
+
+	// DropPlaceholder, when set, is written for each character that can't
+	// be decoded: 16-bit units that aren't valid Unicode, which are
+	// otherwise dropped (or written as U+FFFD with ReplaceInvalid), and
+	// bytes of compressed text that are otherwise written as U+FFFD.
+	DropPlaceholder string
+
+	// IncludeHiddenText keeps runs formatted as hidden text, such as index
+	// entries and hidden notes. By default they are left out.
+	IncludeHiddenText bool
+
+	// LineEnding, when set, is written for each paragraph mark and manual
+	// line break. The zero value keeps paragraph marks as the CR Word stores
+	// and drops line breaks, as ParseDoc always has. ParseDocument ignores
+	// it, since paragraphs are split at the marks.
+	LineEnding LineEnding
+
+	// ApplyCaseFormatting uppercases runs formatted as all caps or small
+	// caps, as Word displays them. By default the stored case is kept.
+	ApplyCaseFormatting bool
+
+	// PieceDelimiter is written between the text of consecutive pieces, to
+	// show where piece boundaries fall when diagnosing extraction problems.
+	// Pieces that write no text get none, and it doesn't count toward
+	// MaxChars. ParseDocument ignores it.
+	PieceDelimiter string
+
+	// RepairOffsets works around writers that stored piece offsets a few
+	// bytes off: a piece whose bytes don't look like text is read from the
+	// nearest offset, up to 8 bytes away, where they do. This is a
+	// heuristic and may misread unusual text, so it is off by default.
+	RepairOffsets bool
+
+	// TrimTrailing strips whitespace, including paragraph marks, and
+	// control characters from the end of the text, where the last
+	// paragraph's mark and any padding left by the writer end up. Text
+	// before them is kept as is.
+	TrimTrailing bool
+
+	// DropFinalMark leaves out the paragraph mark that ends every Word
+	// document, the main text's last character. By default it is written
+	// like any other, so the text ends with exactly one line ending after
+	// the last paragraph, however the pieces are split. Blank paragraphs
+	// before it are kept; TrimTrailing removes those too.
+	DropFinalMark bool
+
+	// CollapseWhitespace replaces each run of spaces and tabs, such as
+	// those left by alignment tabs and cell padding, with a single space.
+	// Paragraph marks and line breaks are kept.
+	CollapseWhitespace bool
+
+	// DetectCompressionMismatch reads a piece as the other kind of text when
+	// its fCompressed flag is plainly wrong: 8-bit text full of NULs that
+	// reads cleanly as UTF-16, or UTF-16 text made up of pairs of printable
+	// ASCII bytes that reads cleanly as 8-bit text. Off by default, as
+	// pieces that pass neither check are always read as flagged.
+	DetectCompressionMismatch bool
+
+	// CellSeparator, when set, is written for each table cell mark, and
+	// the mark ending each table row is written as LineEnding, or "\n"
+	// if that is empty, keeping the table's rows on separate lines. By
+	// default both are written as a space.
+	CellSeparator string
+
+	// OnDecodeWarning, when set, is called for each byte of compressed
+	// text that can't be decoded and is written as U+FFFD: bytes CP1252
+	// leaves undefined, and bytes the code page's or the custom decoder
+	// rejects. offset is the byte's position in the WordDocument stream.
+	// Many of them suggest the document is in another code page.
+	OnDecodeWarning func(offset int, b byte)
+
+	// OnProgress, when set, is called after each piece of text is read
+	// with the bytes of text read so far and the size of the input, or -1
+	// if it can't be told (an io.ReaderAt whose size isn't known). As the
+	// input also holds the file's structures, readBytes stays below
+	// totalBytes.
+	OnProgress func(readBytes, totalBytes int64)
+
+	// NormalizeSpaces writes typographic spaces, such as the em space
+	// (U+2003), en space (U+2002), thin space (U+2009) and non-breaking
+	// space, as a plain space. By default they are kept as stored.
+	NormalizeSpaces bool
+
+	// OnTruncated, when set, is called if the WordDocument stream ends
+	// before the text does, as in files cut short, with the CP at which
+	// the text stops. What the stream still holds is returned either way.
+	OnTruncated func(cp int)
+
+	// TryAlternateTable retries with the other table stream (0Table or
+	// 1Table) when the piece table can't be read from the one the FIB
+	// selects, recovering some damaged files that keep both. The error
+	// from the selected stream is returned if the other one fails too.
+	TryAlternateTable bool
+
+	// StreamNameNormalizer, when set, maps the name of each stream before
+	// it is matched against WordDocument, 0Table and 1Table, for files
+	// from tools that spell them differently, such as with another case
+	StreamNameNormalizer func(string) string
+
+	// LinkFormat controls how the result of HYPERLINK fields is written.
+	// The zero value writes only the display text.
+	LinkFormat LinkFormat
+
+	// ObjectMarker is written in place of each embedded OLE object, with
+	// any "%s" replaced by the object's ProgID, such as "Excel.Sheet.8",
+	// read from its storage in the ObjectPool. Objects whose ProgID can't
+	// be read give their user type name or "object". Empty means objects
+	// are dropped, like pictures.
+	ObjectMarker string
+
+	// Extract selects what Extract reads besides the text. Other functions
+	// ignore it.
+	Extract ExtractFields
+}
+
+// LineEnding is the text written for paragraph marks and line breaks
+type LineEnding string
+
+// Line endings for Options.LineEnding
+const (
+	LF   LineEnding = "\n"
+	CRLF LineEnding = "\r\n"
+	CR   LineEnding = "\r"
+)
+
+// LinkFormat is how hyperlinks appear in the text
+type LinkFormat int
+
+// Link formats for Options.LinkFormat
+const (
+	// LinkText writes a hyperlink's display text only
+	LinkText LinkFormat = iota
+	// LinkTextURL follows the display text with the target in angle
+	// brackets, as in "click here <https://example.com>"
+	LinkTextURL
+)
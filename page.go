@@ -0,0 +1,59 @@
+package doc
+
+import (
+	"io"
+	"strings"
+)
+
+// PageReader returns the text of a document a page at a time. Pages end at
+// manual page breaks and section breaks; the layout Word computes when
+// displaying a document isn't stored in the file.
+type PageReader struct {
+	text   string
+	breaks []int
+	start  int
+	done   bool
+}
+
+// NewPageReader extracts the text of the .doc file in r for reading page by
+// page with NextPage
+func NewPageReader(r io.Reader) (*PageReader, error) {
+	ra, release, err := readerAt(r, Options{})
+	if err != nil {
+		return nil, wrapError(err)
+	}
+	defer release()
+
+	pd, err := openDoc(ra, Options{})
+	if err != nil {
+		return nil, wrapError(err)
+	}
+	w := newTextWriter(Options{})
+	if err := writeText(pd, w); err != nil {
+		return nil, wrapError(err)
+	}
+	text, breaks := w.buf.String(), w.pageBreaks
+	if n := len(breaks); n > 0 && strings.TrimSpace(text[breaks[n-1]:]) == "" {
+		// a document ending in a page break still ends with its final
+		// paragraph mark, which doesn't make another page
+		text, breaks = text[:breaks[n-1]], breaks[:n-1]
+	}
+	return &PageReader{text: text, breaks: breaks}, nil
+}
+
+// NextPage returns the text of the next page, without the break ending it.
+// It returns io.EOF after the last page. A break at the end of the document
+// doesn't give an empty page after it.
+func (p *PageReader) NextPage() (string, error) {
+	if p.done {
+		return "", io.EOF
+	}
+	if len(p.breaks) == 0 {
+		p.done = true
+		return p.text[p.start:], nil
+	}
+	page := p.text[p.start:p.breaks[0]]
+	p.start = p.breaks[0]
+	p.breaks = p.breaks[1:]
+	return page, nil
+}
@@ -0,0 +1,79 @@
+package doc
+
+import (
+	"bytes"
+	"io"
+	"reflect"
+	"testing"
+)
+
+func TestPageReader(t *testing.T) {
+	b := testDoc{pieces: []testPiece{
+		{text: "Cover page\r\x0cContents\r", compressed: true},
+		{text: "\x0cChapter one\r"},
+	}}.build()
+
+	pr, err := NewPageReader(bytes.NewReader(b))
+	if err != nil {
+		t.Fatal("expected successful parse", err)
+	}
+	var pages []string
+	for {
+		page, err := pr.NextPage()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		pages = append(pages, page)
+	}
+	expected := []string{"Cover page\r", "Contents\r", "Chapter one\r"}
+	if !reflect.DeepEqual(pages, expected) {
+		t.Errorf("expected %q, got %q", expected, pages)
+	}
+
+	pr, err = NewPageReader(bytes.NewReader(testDoc{pieces: []testPiece{{text: "One page\r", compressed: true}}}.build()))
+	if err != nil {
+		t.Fatal("expected successful parse", err)
+	}
+	if page, err := pr.NextPage(); err != nil || page != "One page\r" {
+		t.Errorf("expected a single page, got |%s| %v", page, err)
+	}
+	if _, err := pr.NextPage(); err != io.EOF {
+		t.Errorf("expected io.EOF after the last page, got %v", err)
+	}
+}
+
+func TestPageReaderTrailingBreak(t *testing.T) {
+	for name, text := range map[string]string{
+		"final mark":   "First page\r\x0cSecond page\r\x0c\r",
+		"no mark":      "First page\r\x0cSecond page\r\x0c",
+		"blank":        "First page\r\x0cSecond page\r\x0c \r\r",
+		"section mark": "First page\r\x0cSecond page\r\x0c",
+	} {
+		d := testDoc{pieces: []testPiece{{text: text, compressed: true}}}
+		if name == "section mark" {
+			d.sections = []int{11, len(text)}
+		}
+		pr, err := NewPageReader(bytes.NewReader(d.build()))
+		if err != nil {
+			t.Fatal(name, err)
+		}
+		var pages []string
+		for {
+			page, err := pr.NextPage()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				t.Fatal(name, err)
+			}
+			pages = append(pages, page)
+		}
+		expected := []string{"First page\r", "Second page\r"}
+		if !reflect.DeepEqual(pages, expected) {
+			t.Errorf("%s: expected %q, got %q", name, expected, pages)
+		}
+	}
+}
@@ -0,0 +1,101 @@
+package doc
+
+import (
+	"encoding/binary"
+	"sort"
+
+	"github.com/richardlehane/mscfb"
+)
+
+// paraProps holds the paragraph properties this package reads from a Papx
+type paraProps struct {
+	istd    uint16 // index of the paragraph's style in the stylesheet
+	rtl     bool
+	inTable bool
+	ttp     bool // the paragraph ends a table row
+}
+
+// papxRun is a range [fc, fcEnd) of bytes in the WordDocument stream whose
+// paragraph marks share the same paragraph properties
+type papxRun struct {
+	fc, fcEnd int
+	props     paraProps
+}
+
+// getPapxRuns reads the paragraph properties of the document from the
+// PapxFkp pages listed in PlcBtePapx (section 2.8.7), in FC order
+func getPapxRuns(wordDoc, table *mscfb.File, fib *fib) ([]papxRun, error) {
+	var runs []papxRun
+	err := readFkps(wordDoc, table, fib.fibRgFcLcb.fcPlcfBtePapx, fib.fibRgFcLcb.lcbPlcfBtePapx, func(page []byte) error {
+		fkp, err := parsePapxFkp(page)
+		runs = append(runs, fkp...)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(runs, func(i, j int) bool { return runs[i].fc < runs[j].fc })
+	return runs, nil
+}
+
+// parse a PapxFkp (section 2.9.175)
+func parsePapxFkp(page []byte) ([]papxRun, error) {
+	const bxPapSize = 13
+	cpara := int(page[511])
+	rgbxStart := (cpara + 1) * 4
+	if cpara == 0 || rgbxStart+cpara*bxPapSize > 511 {
+		return nil, errInvalidFkp
+	}
+
+	runs := make([]papxRun, cpara)
+	for i := range runs {
+		runs[i].fc = getInt(page, i*4)
+		runs[i].fcEnd = getInt(page, (i+1)*4)
+		offset := int(page[rgbxStart+i*bxPapSize]) * 2
+		if offset == 0 {
+			continue
+		}
+
+		// PapxInFkp (section 2.9.174): a count of 16-bit words, or 0 and
+		// then the count, followed by the GrpPrlAndIstd
+		if offset+2 > 511 {
+			return nil, errInvalidFkp
+		}
+		size := 2*int(page[offset]) - 1
+		start := offset + 1
+		if page[offset] == 0 {
+			size = 2 * int(page[offset+1])
+			start = offset + 2
+		}
+		if size < 2 || start+size > 511 {
+			return nil, errInvalidFkp
+		}
+		runs[i].props = parseParaProps(page[start+2 : start+size])
+		runs[i].props.istd = binary.LittleEndian.Uint16(page[start:])
+	}
+	return runs, nil
+}
+
+func parseParaProps(grpprl []byte) paraProps {
+	var p paraProps
+	forEachSprm(grpprl, func(sprm uint16, operand []byte) {
+		switch sprm {
+		case sprmPFBiDi:
+			p.rtl = operand[0] != 0
+		case sprmPFInTable:
+			p.inTable = operand[0] != 0
+		case sprmPFTtp:
+			p.ttp = operand[0] != 0
+		}
+	})
+	return p
+}
+
+// paraPropsAt returns the properties of the paragraph whose mark is at fc
+func paraPropsAt(runs []papxRun, fc int) paraProps {
+	i := sort.Search(len(runs), func(i int) bool { return runs[i].fcEnd > fc })
+	if i < len(runs) && runs[i].fc <= fc {
+		return runs[i].props
+	}
+	return paraProps{}
+}
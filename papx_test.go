@@ -0,0 +1,38 @@
+package doc
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestParseRTL(t *testing.T) {
+	bidi := []byte{0x41, 0x24, 0x01} // sprmPFBiDi on
+	b := testDoc{lid: 0x0401, pieces: []testPiece{
+		{text: "English heading\r", compressed: true},
+		{text: "مرحبا بالعالم\r", papx: bidi, grpprl: []byte{0x5A, 0x08, 0x01}},
+		{text: "Closing\r", compressed: true},
+	}}.build()
+
+	d, err := ParseDocument(bytes.NewReader(b))
+	if err != nil {
+		t.Fatal("expected successful parse", err)
+	}
+	if len(d.Paragraphs) != 3 {
+		t.Fatalf("expected 3 paragraphs, got %d", len(d.Paragraphs))
+	}
+	for i, rtl := range []bool{false, true, false} {
+		if d.Paragraphs[i].RTL != rtl {
+			t.Errorf("expected RTL %v for paragraph %d |%s|", rtl, i, d.Paragraphs[i].Text())
+		}
+	}
+	if s := d.Paragraphs[1].Text(); s != "مرحبا بالعالم" {
+		t.Errorf("expected text in logical order |%s|", s)
+	}
+
+	if rtl, err := HasRTL(bytes.NewReader(b)); err != nil || !rtl {
+		t.Errorf("expected HasRTL, got %v %v", rtl, err)
+	}
+	if rtl, err := HasRTL(bytes.NewReader(richDoc.build())); err != nil || rtl {
+		t.Errorf("expected no RTL text, got %v %v", rtl, err)
+	}
+}
@@ -0,0 +1,54 @@
+package doc
+
+import (
+	"encoding/binary"
+	"errors"
+
+	"github.com/richardlehane/mscfb"
+)
+
+var (
+	errInvalidPlc = errors.New("invalid PLC structure")
+)
+
+// plc is a PLC structure (section 2.2.2): an array of n+1 CPs followed by
+// n data elements of the same size
+type plc struct {
+	aCP   []int
+	aData [][]byte
+}
+
+// parse a PLC whose data elements are cbData bytes long
+func parsePlc(b []byte, cbData int) (*plc, error) {
+	if len(b) < 4 || (len(b)-4)%(4+cbData) != 0 {
+		return nil, errInvalidPlc
+	}
+	n := (len(b) - 4) / (4 + cbData) // see 2.2.2 in the spec for equation
+
+	cps := make([]int, n+1)
+	for i := range cps {
+		cps[i] = int(binary.LittleEndian.Uint32(b[i*4 : i*4+4]))
+	}
+	dataStart := (n + 1) * 4
+	data := make([][]byte, n)
+	for i := range data {
+		data[i] = b[dataStart+i*cbData : dataStart+(i+1)*cbData]
+	}
+	return &plc{aCP: cps, aData: data}, nil
+}
+
+// readTableBytes reads lcb bytes at fc from the table stream. A zero lcb
+// means the structure isn't present and yields nil.
+func readTableBytes(table *mscfb.File, fc, lcb int) ([]byte, error) {
+	if lcb == 0 {
+		return nil, nil
+	}
+	if fc < 0 || lcb < 0 || int64(fc)+int64(lcb) > table.Size {
+		return nil, errInvalidArgument
+	}
+	b := make([]byte, lcb)
+	if _, err := table.ReadAt(b, int64(fc)); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
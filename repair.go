@@ -0,0 +1,110 @@
+package doc
+
+import (
+	"bytes"
+
+	"github.com/richardlehane/mscfb"
+)
+
+// maxOffsetShift is the furthest, in bytes, RepairOffsets moves a piece
+const maxOffsetShift = 8
+
+// offsetShift returns how far to move the piece stored at [start, end) in
+// the WordDocument stream so that it reads as text: 0 if it already does
+// or no nearby offset does better, else the smallest shift giving text
+// without a single suspect character
+func offsetShift(wordDoc *mscfb.File, start, end int, compressed bool) (int, error) {
+	from := max(start-maxOffsetShift, 0)
+	to := min(int64(end+maxOffsetShift), wordDoc.Size)
+	b := make([]byte, int(to)-from)
+	if _, err := wordDoc.ReadAt(b, int64(from)); err != nil {
+		return 0, err
+	}
+
+	looksLikeText := func(shift int) bool {
+		s, e := start+shift-from, end+shift-from
+		if s < 0 || e > len(b) {
+			return false
+		}
+		return suspectChars(b[s:e], compressed) == 0
+	}
+	if looksLikeText(0) {
+		return 0, nil
+	}
+	for d := 1; d <= maxOffsetShift; d++ {
+		if looksLikeText(d) {
+			return d, nil
+		}
+		if looksLikeText(-d) {
+			return -d, nil
+		}
+	}
+	return 0, nil
+}
+
+// suspectChars counts the characters of a piece that are unlikely in text:
+// control characters Word doesn't use, and for Unicode text noncharacters
+// and the units ASCII text gives when read one byte off
+func suspectChars(b []byte, compressed bool) int {
+	n := 0
+	if compressed {
+		for _, c := range b {
+			if !isTextControl(uint16(c)) {
+				n++
+			}
+		}
+		return n
+	}
+	for i := 0; i+1 < len(b); i += 2 {
+		u := uint16(b[i]) | uint16(b[i+1])<<8
+		switch {
+		case !isTextControl(u), u == 0xFFFE, u == 0xFFFF:
+			n++
+		case u&0xFF == 0 && isPrintableASCII(byte(u>>8)):
+			n++
+		}
+	}
+	return n
+}
+
+// isTextControl reports whether c is a character, one of the control
+// characters Word stores in text or a special character standing for a
+// picture, reference, column break, drawing or hyphen
+func isTextControl(c uint16) bool {
+	switch c {
+	case 0x01, 0x02, 0x05, 0x08, 0x0E, 0x1E, 0x1F:
+		return true
+	}
+	return c >= 0x20 || isWordControl(rune(c))
+}
+
+// compressionMismatch reports whether the n characters of the piece at
+// start in the WordDocument stream are plainly stored the other way from
+// what compressed says (see Options.DetectCompressionMismatch)
+func compressionMismatch(wordDoc *mscfb.File, start, n int, compressed bool) (bool, error) {
+	if start < 0 || int64(start) >= wordDoc.Size {
+		return false, nil
+	}
+	b := make([]byte, min(int64(2*n), wordDoc.Size-int64(start)))
+	if _, err := wordDoc.ReadAt(b, int64(start)); err != nil {
+		return false, err
+	}
+
+	if compressed {
+		if len(b) < 2*n {
+			return false, nil
+		}
+		nuls := bytes.Count(b[:n], []byte{0})
+		return nuls*4 >= n && suspectChars(b, false) == 0, nil
+	}
+	if n < 2 {
+		return false, nil
+	}
+	asciiPairs := 0
+	for i := 0; i+1 < n; i += 2 {
+		if isPrintableASCII(b[i]) && isPrintableASCII(b[i+1]) {
+			asciiPairs++
+		}
+	}
+	return asciiPairs*4 >= 3*(n/2) && suspectChars(b[:n], true) == 0, nil
+}
@@ -0,0 +1,88 @@
+package doc
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+)
+
+func TestParseRepairOffsets(t *testing.T) {
+	const text = "Shifted text\r"
+	for _, compressed := range []bool{true, false} {
+		piece := testPiece{text: text, compressed: compressed}
+		_, n := piece.encode()
+
+		// the only piece starts 3 bytes before the text, in the zero
+		// padding after the FIB
+		clx := clxBytes([]int{0, n}, []int{testTextOffset - 3}, []bool{compressed})
+		b := testDoc{pieces: []testPiece{piece}, clx: clx}.build()
+
+		r, err := ParseDoc(bytes.NewReader(b))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got, _ := io.ReadAll(r); string(got) == text {
+			t.Fatalf("compressed %v: shifted piece read correctly without repair", compressed)
+		}
+
+		r, err = ParseDocWithOptions(bytes.NewReader(b), Options{RepairOffsets: true})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got, _ := io.ReadAll(r); string(got) != text {
+			t.Errorf("compressed %v: got %q, want %q", compressed, got, text)
+		}
+	}
+}
+
+func TestParseRepairOffsetsKeepsValidPieces(t *testing.T) {
+	b := testDoc{pieces: []testPiece{
+		{text: "Compressed, ", compressed: true},
+		{text: "Unicode 中文\r"},
+	}}.build()
+	r, err := ParseDocWithOptions(bytes.NewReader(b), Options{RepairOffsets: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := io.ReadAll(r); string(got) != "Compressed, Unicode 中文\r" {
+		t.Errorf("got %q", got)
+	}
+}
+
+func TestParseCompressionMismatch(t *testing.T) {
+	// UTF-16 text flagged as compressed, then 8-bit text flagged as
+	// UTF-16, followed by a correctly flagged piece
+	pieces := []testPiece{
+		{text: "Really UTF-16. "},
+		{text: "Really 8-bit. ", compressed: true},
+		{text: "Fine.\r", compressed: true},
+	}
+	cps := []int{0, 15, 29, 35}
+	offsets := []int{testTextOffset, testTextOffset + 30, testTextOffset + 44}
+	clx := clxBytes(cps, offsets, []bool{true, false, true})
+	b := testDoc{pieces: pieces, clx: clx}.build()
+
+	// read as flagged, the second piece runs past the end of the stream
+	if _, err := ParseDoc(bytes.NewReader(b)); !errors.Is(err, errInvalidArgument) {
+		t.Fatalf("expected errInvalidArgument without detection, got %v", err)
+	}
+
+	r, err := ParseDocWithOptions(bytes.NewReader(b), Options{DetectCompressionMismatch: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := io.ReadAll(r); string(got) != "Really UTF-16. Really 8-bit. Fine.\r" {
+		t.Errorf("got %q", got)
+	}
+
+	// correctly flagged pieces are left alone
+	b = testDoc{pieces: []testPiece{{text: "Ünïcode 中文 "}, {text: "8-bit été\r", compressed: true}}}.build()
+	r, err = ParseDocWithOptions(bytes.NewReader(b), Options{DetectCompressionMismatch: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := io.ReadAll(r); string(got) != "Ünïcode 中文 8-bit été\r" {
+		t.Errorf("got %q", got)
+	}
+}
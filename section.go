@@ -0,0 +1,112 @@
+package doc
+
+import (
+	"encoding/binary"
+	"io"
+
+	"github.com/richardlehane/mscfb"
+)
+
+// Section describes a section of the main document
+type Section struct {
+	Start, End int // CPs of the first character and just past the section mark
+	// Columns is the number of text columns the section is laid out in.
+	// Text is stored column by column, so downstream tools can only split
+	// it at column breaks, not reorder lines.
+	Columns int
+}
+
+// ReadSections returns the sections of the main document of the .doc file
+// in r. A document without section descriptors has a single section.
+func ReadSections(r io.Reader) ([]Section, error) {
+	ra, release, err := readerAt(r, Options{})
+	if err != nil {
+		return nil, wrapError(err)
+	}
+	defer release()
+
+	pd, err := openDoc(ra, Options{})
+	if err != nil {
+		return nil, wrapError(err)
+	}
+	sections, err := getSections(pd.wordDoc, pd.table, pd.fib)
+	if err != nil {
+		return nil, wrapError(err)
+	}
+	return sections, nil
+}
+
+// getSections reads the sections of the main document from PlcfSed and
+// the Sepx each Sed points to (sections 2.8.26 and 2.9.260)
+func getSections(wordDoc, table *mscfb.File, fib *fib) ([]Section, error) {
+	b, err := readTableBytes(table, fib.fibRgFcLcb.fcPlcfSed, fib.fibRgFcLcb.lcbPlcfSed)
+	if err != nil {
+		return nil, err
+	}
+	if b == nil {
+		return []Section{{End: fib.fibRgLw.ccpText, Columns: 1}}, nil
+	}
+	plcfSed, err := parsePlc(b, 12)
+	if err != nil {
+		return nil, err
+	}
+
+	sections := make([]Section, len(plcfSed.aData))
+	for i, sed := range plcfSed.aData {
+		sections[i] = Section{Start: plcfSed.aCP[i], End: plcfSed.aCP[i+1], Columns: 1}
+		fcSepx := binary.LittleEndian.Uint32(sed[2:])
+		if fcSepx == 0xFFFFFFFF { // default properties
+			continue
+		}
+		grpprl, err := readSepx(wordDoc, int64(fcSepx))
+		if err != nil {
+			return nil, err
+		}
+		forEachSprm(grpprl, func(sprm uint16, operand []byte) {
+			if sprm == sprmSCcolumns {
+				sections[i].Columns = int(binary.LittleEndian.Uint16(operand)) + 1
+			}
+		})
+	}
+	return sections, nil
+}
+
+// readSepx returns the grpprl of the Sepx at fc in the WordDocument stream
+func readSepx(wordDoc *mscfb.File, fc int64) ([]byte, error) {
+	cb := make([]byte, 2)
+	if fc+2 > wordDoc.Size {
+		return nil, errInvalidArgument
+	}
+	if _, err := wordDoc.ReadAt(cb, fc); err != nil {
+		return nil, err
+	}
+	n := int64(binary.LittleEndian.Uint16(cb))
+	if fc+2+n > wordDoc.Size {
+		return nil, errInvalidArgument
+	}
+	grpprl := make([]byte, n)
+	if _, err := wordDoc.ReadAt(grpprl, fc+2); err != nil {
+		return nil, err
+	}
+	return grpprl, nil
+}
+
+// getSectionMarks returns the CPs of the section marks (0x0C) ending each
+// section of the main document, read from PlcfSed (section 2.8.26). Other
+// 0x0C characters in the text are page breaks.
+func getSectionMarks(table *mscfb.File, fib *fib) (map[int]bool, error) {
+	b, err := readTableBytes(table, fib.fibRgFcLcb.fcPlcfSed, fib.fibRgFcLcb.lcbPlcfSed)
+	if err != nil || b == nil {
+		return nil, err
+	}
+	plcfSed, err := parsePlc(b, 12) // Sed is 12 bytes (section 2.9.260)
+	if err != nil {
+		return nil, err
+	}
+
+	marks := make(map[int]bool, len(plcfSed.aData))
+	for _, cp := range plcfSed.aCP[1:] {
+		marks[cp-1] = true
+	}
+	return marks, nil
+}
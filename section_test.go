@@ -0,0 +1,76 @@
+package doc
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+func TestParseSectionBreak(t *testing.T) {
+	sectioned := testDoc{
+		pieces: []testPiece{
+			{text: "Section one\x0cpage two\x0c", compressed: true},
+			{text: "Section two\r"},
+		},
+		sections: []int{21, 33},
+	}.build()
+
+	buf, err := ParseDocWithOptions(bytes.NewReader(sectioned), Options{SectionBreak: "\n=====\n"})
+	if err != nil {
+		t.Fatal("expected successful parse", err)
+	}
+	if s := buf.(*bytes.Buffer).String(); s != "Section onepage two\n=====\nSection two\r" {
+		t.Errorf("expected correct value |%s|", s)
+	}
+
+	buf, err = ParseDoc(bytes.NewReader(sectioned))
+	if err != nil {
+		t.Fatal("expected successful parse", err)
+	}
+	if s := buf.(*bytes.Buffer).String(); s != "Section onepage twoSection two\r" {
+		t.Errorf("expected section marks to be dropped by default |%s|", s)
+	}
+}
+
+func TestParseColumnBreak(t *testing.T) {
+	b := testDoc{
+		pieces: []testPiece{
+			{text: "Left column\x0eright column\x0cnext page", compressed: true},
+			{text: "\x0eсправа\r"},
+		},
+		sections: []int{42},
+	}.build()
+
+	buf, err := ParseDocWithOptions(bytes.NewReader(b), Options{ColumnBreak: "\n---\n", SectionBreak: "\n===\n"})
+	if err != nil {
+		t.Fatal("expected successful parse", err)
+	}
+	if s := buf.(*bytes.Buffer).String(); s != "Left column\n---\nright columnnext page\n---\nсправа\r" {
+		t.Errorf("expected correct value |%s|", s)
+	}
+}
+
+func TestReadSections(t *testing.T) {
+	b := testDoc{
+		pieces:   []testPiece{{text: "One column\x0cLeft\x0eright\x0c", compressed: true}},
+		sections: []int{11, 23},
+		sepxs:    [][]byte{nil, {0x0B, 0x50, 0x01, 0x00}}, // sprmSCcolumns: 2 columns
+	}.build()
+
+	sections, err := ReadSections(bytes.NewReader(b))
+	if err != nil {
+		t.Fatal("expected successful read", err)
+	}
+	expected := []Section{{Start: 0, End: 11, Columns: 1}, {Start: 11, End: 23, Columns: 2}}
+	if !reflect.DeepEqual(sections, expected) {
+		t.Errorf("expected %+v, got %+v", expected, sections)
+	}
+
+	sections, err = ReadSections(bytes.NewReader(testDoc{pieces: []testPiece{{text: "x\r", compressed: true}}}.build()))
+	if err != nil {
+		t.Fatal("expected successful read", err)
+	}
+	if expected := []Section{{End: 2, Columns: 1}}; !reflect.DeepEqual(sections, expected) {
+		t.Errorf("expected %+v, got %+v", expected, sections)
+	}
+}
@@ -0,0 +1,63 @@
+package doc
+
+import "encoding/binary"
+
+// sprms read by this package (section 2.6)
+const (
+	sprmCFOle2       = 0x080A
+	sprmCFSmallCaps  = 0x083A
+	sprmCFCaps       = 0x083B
+	sprmCFVanish     = 0x083C
+	sprmCFSpec       = 0x0855
+	sprmCFObj        = 0x0856
+	sprmCFBiDi       = 0x085A
+	sprmPFInTable    = 0x2416
+	sprmPFTtp        = 0x2417
+	sprmPFBiDi       = 0x2441
+	sprmSCcolumns    = 0x500B
+	sprmCLidBi       = 0x485F
+	sprmCRgLid0_80   = 0x486D
+	sprmCRgLid1_80   = 0x486E
+	sprmCRgLid0      = 0x4873
+	sprmCRgLid1      = 0x4874
+	sprmCPicLocation = 0x6A03
+	sprmTDefTable    = 0xD608
+)
+
+// forEachSprm calls f with each Sprm in grpprl and its operand, stopping at
+// the first Prl that runs past the end of grpprl (section 2.6.1)
+func forEachSprm(grpprl []byte, f func(sprm uint16, operand []byte)) {
+	for len(grpprl) >= 2 {
+		sprm := binary.LittleEndian.Uint16(grpprl)
+		b := grpprl[2:]
+
+		var size int
+		switch sprm >> 13 { // spra
+		case 0, 1:
+			size = 1
+		case 2, 4, 5:
+			size = 2
+		case 3:
+			size = 4
+		case 7:
+			size = 3
+		case 6: // variable length, prefixed with its size
+			if sprm == sprmTDefTable { // the one sprm with a 2-byte size
+				if len(b) < 2 {
+					return
+				}
+				size = 2 + int(binary.LittleEndian.Uint16(b)) - 1
+			} else {
+				if len(b) < 1 {
+					return
+				}
+				size = 1 + int(b[0])
+			}
+		}
+		if size > len(b) {
+			return
+		}
+		f(sprm, b[:size])
+		grpprl = b[size:]
+	}
+}
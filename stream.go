@@ -0,0 +1,70 @@
+package doc
+
+import (
+	"errors"
+	"io"
+	"strings"
+)
+
+var (
+	// ErrNoDataStream is returned by RawDataStream for documents without a
+	// Data stream
+	ErrNoDataStream = errors.New("document has no Data stream")
+)
+
+// StreamInfo describes a stream of a compound file
+type StreamInfo struct {
+	// Path names the stream and the storages holding it, separated by
+	// "/", such as "WordDocument" or "ObjectPool/_1234/WordDocument"
+	Path string
+	Size int64
+}
+
+// ListStreams lists the streams of the compound file in r, in directory
+// order. The WordDocument, table and Data streams of a .doc file are among
+// them, as are those of embedded objects.
+func ListStreams(r io.Reader) ([]StreamInfo, error) {
+	ra, release, err := readerAt(r, Options{})
+	if err != nil {
+		return nil, wrapError(err)
+	}
+	defer release()
+
+	d, err := newCFB(ra)
+	if err != nil {
+		return nil, wrapError(err)
+	}
+	streams := []StreamInfo{}
+	for _, f := range d.File {
+		if f.FileInfo().IsDir() {
+			continue
+		}
+		path := strings.Join(append(append([]string{}, f.Path...), f.Name), "/")
+		streams = append(streams, StreamInfo{Path: path, Size: f.Size})
+	}
+	return streams, nil
+}
+
+// RawDataStream returns the contents of the Data stream of the .doc file
+// in r, which holds pictures and the data of some fields and form controls
+func RawDataStream(r io.Reader) ([]byte, error) {
+	ra, release, err := readerAt(r, Options{})
+	if err != nil {
+		return nil, wrapError(err)
+	}
+	defer release()
+
+	d, err := newCFB(ra)
+	if err != nil {
+		return nil, wrapError(err)
+	}
+	data := getStreamAt(d, nil, "Data")
+	if data == nil {
+		return nil, wrapError(ErrNoDataStream)
+	}
+	b := make([]byte, data.Size)
+	if _, err := data.ReadAt(b, 0); err != nil {
+		return nil, wrapError(err)
+	}
+	return b, nil
+}
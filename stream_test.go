@@ -0,0 +1,54 @@
+package doc
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func TestListStreams(t *testing.T) {
+	data := bytes.Repeat([]byte{0xAB}, 300)
+	embedded := testDoc{pieces: []testPiece{{text: "embedded\r", compressed: true}}}.entries()
+	b := testDoc{
+		pieces: []testPiece{{text: "main\r", compressed: true}},
+		streams: []cfbEntry{
+			{name: "Data", data: data},
+			{name: "ObjectPool", storage: true, children: []cfbEntry{
+				{name: "_1234", storage: true, children: embedded},
+			}},
+		},
+	}.build()
+
+	streams, err := ListStreams(bytes.NewReader(b))
+	if err != nil {
+		t.Fatal(err)
+	}
+	sizes := map[string]int64{}
+	for _, s := range streams {
+		sizes[s.Path] = s.Size
+	}
+	for _, path := range []string{"WordDocument", "1Table", "ObjectPool/_1234/WordDocument", "ObjectPool/_1234/1Table"} {
+		if sizes[path] == 0 {
+			t.Errorf("expected stream %s in %v", path, streams)
+		}
+	}
+	if sizes["Data"] != int64(len(data)) {
+		t.Errorf("expected Data stream of %d bytes, got %d", len(data), sizes["Data"])
+	}
+	if _, ok := sizes["ObjectPool"]; ok {
+		t.Error("storages should not be listed")
+	}
+
+	raw, err := RawDataStream(bytes.NewReader(b))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(raw, data) {
+		t.Errorf("Data stream differs: got %d bytes", len(raw))
+	}
+
+	noData := testDoc{pieces: []testPiece{{text: "main\r", compressed: true}}}.build()
+	if _, err := RawDataStream(bytes.NewReader(noData)); !errors.Is(err, ErrNoDataStream) {
+		t.Errorf("expected ErrNoDataStream, got %v", err)
+	}
+}
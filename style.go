@@ -0,0 +1,128 @@
+package doc
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+	"strings"
+	"unicode/utf16"
+
+	"github.com/richardlehane/mscfb"
+)
+
+var errInvalidStsh = errors.New("invalid STSH structure")
+
+// style is a paragraph or character style of the stylesheet
+type style struct {
+	name string
+	sti  int // built-in style identifier, 0xFFE for user styles
+}
+
+// isHeading reports whether s is one of the built-in styles Heading 1 to
+// Heading 9, whatever their name in the document's language
+func (s style) isHeading() bool {
+	return s.sti >= 1 && s.sti <= 9
+}
+
+// Headings returns the text of the paragraphs of the .doc file in r that
+// use a built-in heading style, in document order
+func Headings(r io.Reader) ([]string, error) {
+	ra, release, err := readerAt(r, Options{})
+	if err != nil {
+		return nil, wrapError(err)
+	}
+	defer release()
+
+	pd, err := openDoc(ra, Options{})
+	if err != nil {
+		return nil, wrapError(err)
+	}
+
+	headings := []string{}
+	var text strings.Builder
+	w := newTextWriter(Options{})
+	w.walk = func(e RunEvent) error {
+		switch e.Kind {
+		case ParagraphStart:
+			text.Reset()
+		case RunText:
+			text.WriteString(e.Text)
+		case ParagraphEnd:
+			if w.paraStyle.isHeading() {
+				headings = append(headings, text.String())
+			}
+		}
+		return nil
+	}
+	if err := writeText(pd, w); err != nil {
+		return nil, wrapError(err)
+	}
+	return headings, nil
+}
+
+// getStyles reads the styles of the stylesheet (STSH, section 2.9.271),
+// indexed by istd. Empty slots have no name.
+func getStyles(table *mscfb.File, fib *fib) ([]style, error) {
+	b, err := readTableBytes(table, fib.fibRgFcLcb.fcStshf, fib.fibRgFcLcb.lcbStshf)
+	if err != nil || b == nil {
+		return nil, err
+	}
+
+	// LPStshi: the size of the Stshi, which starts with cstd and
+	// cbSTDBaseInFile (section 2.9.272)
+	if len(b) < 6 {
+		return nil, errInvalidStsh
+	}
+	cbStshi := int(binary.LittleEndian.Uint16(b))
+	cstd := int(binary.LittleEndian.Uint16(b[2:]))
+	cbStdBase := int(binary.LittleEndian.Uint16(b[4:]))
+	if 2+cbStshi > len(b) {
+		return nil, errInvalidStsh
+	}
+	b = b[2+cbStshi:]
+
+	styles := make([]style, 0, cstd)
+	for i := 0; i < cstd && len(b) >= 2; i++ {
+		// LPStd: the size of the STD, 0 for an empty slot, then the STD
+		// padded to an even length
+		cbStd := int(binary.LittleEndian.Uint16(b))
+		if 2+cbStd > len(b) {
+			return nil, errInvalidStsh
+		}
+		styles = append(styles, parseStd(b[2:2+cbStd], cbStdBase))
+		b = b[min(2+cbStd+cbStd%2, len(b)):]
+	}
+	return styles, nil
+}
+
+// parseStd reads the sti and name of a STD (section 2.9.260), whose Stdf
+// is cbStdBase bytes long
+func parseStd(b []byte, cbStdBase int) style {
+	if len(b) < 2 || cbStdBase+2 > len(b) {
+		return style{}
+	}
+	s := style{sti: int(binary.LittleEndian.Uint16(b) & 0x0FFF)}
+
+	// xstzName: a count of characters, then the name and a terminating
+	// zero. Aliases follow the primary name after commas.
+	name := b[cbStdBase:]
+	cch := int(binary.LittleEndian.Uint16(name))
+	if 2+cch*2 > len(name) {
+		return s
+	}
+	units := make([]uint16, cch)
+	for i := range units {
+		units[i] = binary.LittleEndian.Uint16(name[2+i*2:])
+	}
+	s.name, _, _ = strings.Cut(string(utf16.Decode(units)), ",")
+	return s
+}
+
+// styleAt returns the style with index istd, or the zero style if there
+// is none
+func styleAt(styles []style, istd uint16) style {
+	if int(istd) < len(styles) {
+		return styles[istd]
+	}
+	return style{}
+}
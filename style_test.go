@@ -0,0 +1,56 @@
+package doc
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+func headingTestDoc() []byte {
+	return testDoc{
+		pieces: []testPiece{
+			{text: "Introduction\r", compressed: true, istd: 1},
+			{text: "Some text.\r", compressed: true},
+			{text: "Zusammenfassung\r", compressed: true, istd: 2},
+			{text: "More text.\r", compressed: true, istd: 3},
+		},
+		styles: []style{
+			{name: "Normal", sti: 0},
+			{name: "heading 1,H1", sti: 1},
+			{name: "Überschrift 2", sti: 2},
+			{name: "Quote", sti: 0xFFE},
+		},
+	}.build()
+}
+
+func TestHeadings(t *testing.T) {
+	headings, err := Headings(bytes.NewReader(headingTestDoc()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"Introduction", "Zusammenfassung"}; !reflect.DeepEqual(headings, want) {
+		t.Errorf("expected %q, got %q", want, headings)
+	}
+
+	headings, err = Headings(bytes.NewReader(testDoc{pieces: []testPiece{{text: "text\r", compressed: true}}}.build()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(headings) != 0 {
+		t.Errorf("expected no headings, got %q", headings)
+	}
+}
+
+func TestParseDocumentStyles(t *testing.T) {
+	d, err := ParseDocument(bytes.NewReader(headingTestDoc()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var styles []string
+	for _, p := range d.Paragraphs {
+		styles = append(styles, p.Style)
+	}
+	if want := []string{"heading 1", "Normal", "Überschrift 2", "Quote"}; !reflect.DeepEqual(styles, want) {
+		t.Errorf("expected styles %q, got %q", want, styles)
+	}
+}
@@ -0,0 +1,70 @@
+package doc
+
+// Table is the text of a table, row by row and cell by cell. Paragraphs
+// within a cell are separated as in the extracted text.
+type Table struct {
+	Rows [][]string `json:"rows"`
+}
+
+// tableBuilder collects the cells of tables as their text is written.
+// Cells end with a cell mark (0x07) and rows with another cell mark in a
+// paragraph flagged as the row end (fTtp); tables end at the first
+// paragraph mark outside a table (section 2.4.3).
+type tableBuilder struct {
+	tables    []Table
+	rows      [][]string // of the table being read
+	cells     []string   // of the row being read
+	cellStart int        // offset in buf of the text of the cell being read
+}
+
+// cellMark handles the cell or row end mark found at fc
+func (w *textWriter) cellMark(fc int) {
+	end := w.buf.Len()
+	rowEnd := paraPropsAt(w.papx, fc).ttp
+	switch {
+	case w.cellSeparator == "":
+		w.writeByte(' ')
+	case rowEnd && w.lineEnding != "":
+		w.writeString(w.lineEnding)
+	case rowEnd:
+		w.writeByte('\n')
+	default:
+		w.writeString(w.cellSeparator)
+	}
+
+	t := w.tables
+	if t == nil {
+		return
+	}
+	if rowEnd {
+		t.rows = append(t.rows, t.cells)
+		t.cells = nil
+	} else {
+		t.cells = append(t.cells, string(w.buf.Bytes()[t.cellStart:end]))
+	}
+	t.cellStart = w.buf.Len()
+}
+
+// tableParagraph ends the table being read, if any, once the paragraph
+// mark at fc has been written outside a table
+func (w *textWriter) tableParagraph(fc int) {
+	t := w.tables
+	if t == nil || paraPropsAt(w.papx, fc).inTable {
+		return
+	}
+	t.end()
+	t.cellStart = w.buf.Len()
+}
+
+// end finishes the table being read, keeping any cells of a row that
+// never got its row end mark
+func (t *tableBuilder) end() {
+	if len(t.cells) > 0 {
+		t.rows = append(t.rows, t.cells)
+		t.cells = nil
+	}
+	if len(t.rows) > 0 {
+		t.tables = append(t.tables, Table{Rows: t.rows})
+		t.rows = nil
+	}
+}
@@ -0,0 +1,69 @@
+package doc
+
+import (
+	"io"
+	"strings"
+
+	"github.com/richardlehane/mscfb"
+)
+
+// ExtractTextboxes returns the text of each text box and shape with text
+// in the main document of the .doc file in r, without trailing paragraph
+// marks. Documents without text boxes give an empty slice.
+func ExtractTextboxes(r io.Reader) ([]string, error) {
+	ra, release, err := readerAt(r, Options{})
+	if err != nil {
+		return nil, wrapError(err)
+	}
+	defer release()
+
+	pd, err := openDoc(ra, Options{})
+	if err != nil {
+		return nil, wrapError(err)
+	}
+	ranges, err := getTextboxes(pd.table, pd.fib)
+	if err != nil {
+		return nil, wrapError(err)
+	}
+
+	texts := []string{}
+	for _, cps := range ranges {
+		w := newTextWriter(Options{})
+		w.cpFrom, w.cpTo = cps[0], cps[1]
+		if err := writeText(pd, w); err != nil {
+			return nil, wrapError(err)
+		}
+		texts = append(texts, strings.TrimRight(w.buf.String(), "\r"))
+	}
+	return texts, nil
+}
+
+// getTextboxes returns the CP range of each text box of the main document.
+// The textbox subdocument follows the main document, footnotes, headers,
+// comments and endnotes, and PlcftxbxTxt splits it into
+// text boxes, the last of which is a dummy.
+func getTextboxes(table *mscfb.File, fib *fib) ([][2]int, error) {
+	lw := fib.fibRgLw
+	if lw.ccpTxbx <= 0 {
+		return nil, nil
+	}
+	base := lw.ccpText + lw.ccpFtn + lw.ccpHdd + lw.ccpMcr + lw.ccpAtn + lw.ccpEdn
+
+	b, err := readTableBytes(table, fib.fibRgFcLcb.fcPlcftxbxTxt, fib.fibRgFcLcb.lcbPlcftxbxTxt)
+	if err != nil {
+		return nil, err
+	}
+	if b == nil {
+		return [][2]int{{base, base + lw.ccpTxbx}}, nil
+	}
+	plc, err := parsePlc(b, 22) // FTXBXS is 22 bytes
+	if err != nil {
+		return nil, err
+	}
+
+	var ranges [][2]int
+	for i := 0; i+1 < len(plc.aData); i++ {
+		ranges = append(ranges, [2]int{base + plc.aCP[i], base + plc.aCP[i+1]})
+	}
+	return ranges, nil
+}
@@ -0,0 +1,39 @@
+package doc
+
+import (
+	"bytes"
+	"io"
+	"reflect"
+	"testing"
+)
+
+func TestExtractTextboxes(t *testing.T) {
+	b := testDoc{
+		pieces:    []testPiece{{text: "Main text\r", compressed: true}},
+		textboxes: []string{"In a box\r"},
+	}.build()
+
+	boxes, err := ExtractTextboxes(bytes.NewReader(b))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := []string{"In a box"}; !reflect.DeepEqual(boxes, expected) {
+		t.Errorf("expected %q, got %q", expected, boxes)
+	}
+
+	buf, err := ParseDoc(bytes.NewReader(b))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s, _ := io.ReadAll(buf); !bytes.HasPrefix(s, []byte("Main text\r")) {
+		t.Errorf("unexpected main text %q", s)
+	}
+
+	boxes, err = ExtractTextboxes(bytes.NewReader(testDoc{pieces: []testPiece{{text: "No boxes\r", compressed: true}}}.build()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if boxes == nil || len(boxes) != 0 {
+		t.Errorf("expected an empty slice, got %#v", boxes)
+	}
+}
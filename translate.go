@@ -0,0 +1,26 @@
+package doc
+
+// TranslateBytes translates the text of a single piece as ParseDocWithOptions
+// would, apart from the compound file and piece table, for benchmarking and
+// fuzzing the decoding step in isolation. Compressed text holds a byte per
+// character, decoded by opts.CustomDecoder or in opts.Codepage, CP1252 by
+// default; other text is UTF-16LE and must have an even length. Fields and
+// paragraph marks are handled as in a document, but there are no character
+// properties, sections or styles.
+func TranslateBytes(b []byte, compressed bool, opts Options) ([]byte, error) {
+	if !compressed && len(b)%2 != 0 {
+		return nil, wrapError(errInvalidArgument)
+	}
+	w := newTextWriter(opts)
+	w.lid = 0x0409
+	f := &fib{base: fibBase{lid: 0x0409, fExtChar: true}}
+
+	finish := w.startDecoding(f)
+	err := translateText(b, w, compressed, f)
+	finish()
+	if err != nil {
+		return nil, wrapError(err)
+	}
+	w.finishText(opts)
+	return w.buf.Bytes(), nil
+}
@@ -0,0 +1,91 @@
+package doc
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"testing"
+
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/encoding/simplifiedchinese"
+)
+
+// utf16LE encodes s as an uncompressed piece
+func utf16LE(s string) []byte {
+	b, _ := testPiece{text: s}.encode()
+	return b
+}
+
+func TestTranslateBytes(t *testing.T) {
+	gbk, err := simplifiedchinese.GBK.NewEncoder().Bytes([]byte("中文\r"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, test := range map[string]struct {
+		b          []byte
+		compressed bool
+		opts       Options
+		expected   string
+	}{
+		"compressed": {[]byte("Caf\xe9 \x13 PAGE \x141\x15\r"), true, Options{}, "Café 1\r"},
+		"code page":  {gbk, true, Options{Codepage: 936}, "中文\r"},
+		"unicode":    {utf16LE("Текст 😀\r"), false, Options{}, "Текст 😀\r"},
+		"options":    {[]byte("Line\rend\r\r"), true, Options{LineEnding: LF, TrimTrailing: true}, "Line\nend"},
+	} {
+		b, err := TranslateBytes(test.b, test.compressed, test.opts)
+		if err != nil {
+			t.Fatal(name, err)
+		}
+		if string(b) != test.expected {
+			t.Errorf("%s: expected %q, got %q", name, test.expected, b)
+		}
+	}
+
+	// the decoder is picked and flushed as for a document
+	b, err := TranslateBytes([]byte("\xcf\xf0\xe8\xe2\xe5\xf2\r"), true, Options{CustomDecoder: charmap.Windows1251.NewDecoder()})
+	if err != nil || string(b) != "Привет\r" {
+		t.Errorf("expected the custom decoder's text, got %q, %v", b, err)
+	}
+	var warnings []int
+	warn := func(offset int, _ byte) { warnings = append(warnings, offset) }
+	if _, err := TranslateBytes([]byte("a\x81b"), true, Options{OnDecodeWarning: warn}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := TranslateBytes(append(gbk, 0xD6), true, Options{Codepage: 936, OnDecodeWarning: warn}); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(warnings, []int{1, len(gbk)}) {
+		t.Errorf("expected warnings for the undefined byte and the lone lead byte, got %v", warnings)
+	}
+
+	if _, err := TranslateBytes([]byte("odd"), false, Options{}); !errors.Is(err, errInvalidArgument) {
+		t.Errorf("expected errInvalidArgument for an odd length, got %v", err)
+	}
+}
+
+func BenchmarkTranslateBytes(b *testing.B) {
+	gbk, err := simplifiedchinese.GBK.NewEncoder().Bytes([]byte("中文测试文档，这是一段较长的中文文本。\r"))
+	if err != nil {
+		b.Fatal(err)
+	}
+	for _, bm := range []struct {
+		name       string
+		text       []byte
+		compressed bool
+		opts       Options
+	}{
+		{"CompressedASCII", bytes.Repeat([]byte("The quick brown fox jumps over the lazy dog.\r"), 2000), true, Options{}},
+		{"CompressedCJK", bytes.Repeat(gbk, 2000), true, Options{Codepage: 936}},
+		{"Unicode", bytes.Repeat(utf16LE("Съешь же ещё этих мягких французских булок.\r"), 2000), false, Options{}},
+	} {
+		b.Run(bm.name, func(b *testing.B) {
+			b.SetBytes(int64(len(bm.text)))
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if _, err := TranslateBytes(bm.text, bm.compressed, bm.opts); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,130 @@
+package doc
+
+import (
+	"io"
+	"strings"
+)
+
+// RunEventKind identifies what a RunEvent reports
+type RunEventKind int
+
+const (
+	// ParagraphStart begins a paragraph. Every RunText and field event
+	// belongs to the paragraph most recently started.
+	ParagraphStart RunEventKind = iota
+	// RunText carries text with the same character properties. Paragraph
+	// marks aren't included.
+	RunText
+	// FieldStart, FieldSeparator and FieldEnd mark the boundaries of a
+	// field. Text between FieldStart and FieldSeparator is the field's
+	// instructions, which aren't reported; text after it is the result.
+	FieldStart
+	FieldSeparator
+	FieldEnd
+	// ParagraphEnd closes the paragraph at its mark. The last paragraph
+	// of a document may lack one.
+	ParagraphEnd
+)
+
+// RunEvent is reported by WalkRuns as the document is decoded
+type RunEvent struct {
+	Kind RunEventKind
+	// Text and Lang are set for RunText, as in Run
+	Text string
+	Lang uint16
+	// RTL is set for RunText of right-to-left runs and ParagraphEnd of
+	// right-to-left paragraphs
+	RTL bool
+	// Style is set for ParagraphEnd to the name of the paragraph's style,
+	// such as "heading 1", if the document has a stylesheet
+	Style string
+}
+
+// WalkRuns decodes the .doc file in r, calling fn for each paragraph, run
+// and field boundary in document order without holding the whole text in
+// memory. An error returned by fn halts the walk and is returned.
+func WalkRuns(r io.Reader, fn func(RunEvent) error) error {
+	ra, release, err := readerAt(r, Options{})
+	if err != nil {
+		return wrapError(err)
+	}
+	defer release()
+
+	pd, err := openDoc(ra, Options{})
+	if err != nil {
+		return wrapError(err)
+	}
+	return walkRuns(pd, Options{}, fn)
+}
+
+// walkRuns translates pd according to opts, reporting the text to fn
+// instead of collecting it. Options that add text at paragraph marks or
+// piece boundaries are ignored.
+func walkRuns(pd *parsedDoc, opts Options, fn func(RunEvent) error) error {
+	opts.LineEnding = ""
+	opts.PieceDelimiter = ""
+	opts.DropFinalMark = false
+	w := newTextWriter(opts)
+	w.walk = fn
+	if err := writeText(pd, w); err != nil {
+		return wrapError(err)
+	}
+	return w.walkErr
+}
+
+// emitEvent reports an event without text to the walk function
+func (w *textWriter) emitEvent(kind RunEventKind) {
+	if w.walk == nil {
+		return
+	}
+	w.startParagraph()
+	w.emit(RunEvent{Kind: kind})
+}
+
+// emitText reports the text collected in buf to the walk function, split
+// at paragraph marks, and empties buf
+func (w *textWriter) emitText() {
+	if w.walk == nil || w.buf.Len() == 0 {
+		return
+	}
+	text := w.buf.String()
+	w.buf.Reset()
+	for {
+		i := strings.IndexByte(text, '\r')
+		if i < 0 {
+			break
+		}
+		w.emitRun(text[:i])
+		var props paraProps
+		if len(w.marks) > 0 {
+			props, w.marks = w.marks[0], w.marks[1:]
+		}
+		w.startParagraph() // for an empty paragraph
+		w.paraStyle = styleAt(w.styles, props.istd)
+		w.emit(RunEvent{Kind: ParagraphEnd, RTL: props.rtl, Style: w.paraStyle.name})
+		w.inParagraph = false
+		text = text[i+1:]
+	}
+	w.emitRun(text)
+}
+
+func (w *textWriter) emitRun(text string) {
+	if text == "" {
+		return
+	}
+	w.startParagraph()
+	w.emit(RunEvent{Kind: RunText, Text: text, Lang: w.props.lang(text, w.lid), RTL: w.props.rtl})
+}
+
+func (w *textWriter) startParagraph() {
+	if !w.inParagraph {
+		w.inParagraph = true
+		w.emit(RunEvent{Kind: ParagraphStart})
+	}
+}
+
+func (w *textWriter) emit(e RunEvent) {
+	if w.walkErr == nil {
+		w.walkErr = w.walk(e)
+	}
+}
@@ -0,0 +1,57 @@
+package doc
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestWalkRuns(t *testing.T) {
+	b := testDoc{pieces: []testPiece{
+		{text: "Title\r\r", compressed: true},
+		{text: "Page \x13 PAGE \x142\x15 of ", compressed: true},
+		{text: "三\r", grpprl: []byte{0x74, 0x48, 0x04, 0x08}}, // sprmCRgLid1 zh-CN
+	}}.build()
+
+	var events []RunEvent
+	err := WalkRuns(bytes.NewReader(b), func(e RunEvent) error {
+		events = append(events, e)
+		return nil
+	})
+	if err != nil {
+		t.Fatal("expected successful walk", err)
+	}
+	expected := []RunEvent{
+		{Kind: ParagraphStart},
+		{Kind: RunText, Text: "Title", Lang: 0x0409},
+		{Kind: ParagraphEnd},
+		{Kind: ParagraphStart},
+		{Kind: ParagraphEnd},
+		{Kind: ParagraphStart},
+		{Kind: RunText, Text: "Page ", Lang: 0x0409},
+		{Kind: FieldStart},
+		{Kind: FieldSeparator},
+		{Kind: RunText, Text: "2", Lang: 0x0409},
+		{Kind: FieldEnd},
+		{Kind: RunText, Text: " of ", Lang: 0x0409},
+		{Kind: RunText, Text: "三", Lang: 0x0804},
+		{Kind: ParagraphEnd},
+	}
+	if !reflect.DeepEqual(events, expected) {
+		t.Errorf("expected events\n%+v\ngot\n%+v", expected, events)
+	}
+
+	halt := errors.New("halt")
+	count := 0
+	err = WalkRuns(bytes.NewReader(b), func(e RunEvent) error {
+		count++
+		if e.Kind == FieldStart {
+			return halt
+		}
+		return nil
+	})
+	if err != halt || count != 8 {
+		t.Errorf("expected the walk to halt at the first field, got %v after %d events", err, count)
+	}
+}